@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generatedHeader first line of the generated model, where and builder files
+const generatedHeader = "// Code generated by bcurd. DO NOT EDIT."
+
+// diffContext lines of context around the changes in the unified diff
+const diffContext = 3
+
+// rendered the files rendered by -check and -dry-run
+var rendered = map[string]bool{}
+
+// outdated the number of the files on disk which are different from the rendered files
+var outdated int
+
+// checkFile compare the rendered file with the file on disk instead of writing it,
+// -check prints the unified diff and -dry-run prints the file name
+func checkFile(filename string, content []byte) {
+	rendered[filepath.Clean(filename)] = true
+	old, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	exist := err == nil
+	if exist && bytes.Equal(old, content) {
+		return
+	}
+	outdated++
+	switch {
+	case dryRun && exist:
+		fmt.Println("update", filename)
+	case dryRun:
+		fmt.Println("create", filename)
+	case exist:
+		fmt.Print(unifiedDiff(old, content, "a/"+filepath.ToSlash(filename), "b/"+filepath.ToSlash(filename)))
+	default:
+		fmt.Print(unifiedDiff(nil, content, "/dev/null", "b/"+filepath.ToSlash(filename)))
+	}
+}
+
+// finishCheck reports the stale generated files when all tables are rendered, such as the package of a dropped table,
+// -check exits 1 when the generated code is out of date
+func finishCheck(all bool) {
+	if !check && !dryRun {
+		return
+	}
+	if all {
+		checkStale()
+	}
+	if check && outdated > 0 {
+		log.Fatalf("%d generated files are out of date, run crud to regenerate them", outdated)
+	}
+}
+
+// checkStale reports the generated files in the output dir which are not rendered
+func checkStale() {
+	err := filepath.Walk(config.Output, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || rendered[filepath.Clean(path)] {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(b), []byte(generatedHeader)) {
+			return nil
+		}
+		outdated++
+		if dryRun {
+			fmt.Println("stale", path)
+			return nil
+		}
+		fmt.Print(unifiedDiff(b, nil, "a/"+filepath.ToSlash(path), "/dev/null"))
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// maxEditDistance caps the different lines the diff searches the shortest edit script for, the memory grows with its square,
+// the files differ in more lines are shown as the whole different part removed and added
+const maxEditDistance = 1024
+
+// edit a line of the unified diff
+type edit struct {
+	op   byte // ' ' same line, '-' line of a, '+' line of b
+	line string
+}
+
+// unifiedDiff returns the unified diff of the lines of a and b, empty if they are equal
+func unifiedDiff(a, b []byte, from, to string) string {
+	x, y := splitLines(a), splitLines(b)
+	var edits []edit
+	// the common prefix and suffix are kept out of the search
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+	for _, v := range x[:pre] {
+		edits = append(edits, edit{' ', v})
+	}
+	edits = append(edits, diffLines(x[pre:len(x)-suf], y[pre:len(y)-suf])...)
+	for _, v := range x[len(x)-suf:] {
+		edits = append(edits, edit{' ', v})
+	}
+
+	// line numbers of a and b before every edit
+	ai, bi := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for k, e := range edits {
+		ai[k+1], bi[k+1] = ai[k], bi[k]
+		if e.op != '+' {
+			ai[k+1]++
+		}
+		if e.op != '-' {
+			bi[k+1]++
+		}
+	}
+	var sb strings.Builder
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			k++
+			continue
+		}
+		// the changes separated by at most 2*diffContext same lines are in one hunk
+		last := k
+		for j := k; j < len(edits) && j-last <= 2*diffContext+1; j++ {
+			if edits[j].op != ' ' {
+				last = j
+			}
+		}
+		start, end := k-diffContext, last+1+diffContext
+		if start < 0 {
+			start = 0
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(ai[start], ai[end]-ai[start]), hunkRange(bi[start], bi[end]-bi[start]))
+		for _, e := range edits[start:end] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+// diffLines returns the shortest edit script of the lines by the Myers algorithm, it takes O((N+M)D) time
+// and O(D^2) memory for the D different lines. The lines are compared by their ids
+func diffLines(x, y []string) []edit {
+	ids := map[string]int32{}
+	intern := func(lines []string) []int32 {
+		res := make([]int32, len(lines))
+		for i, v := range lines {
+			id, ok := ids[v]
+			if !ok {
+				id = int32(len(ids))
+				ids[v] = id
+			}
+			res[i] = id
+		}
+		return res
+	}
+	a, b := intern(x), intern(y)
+	n, m := len(a), len(b)
+	// v[k+offset] is the furthest x on the diagonal k = x - y, trace[d] is v of the d different lines on the diagonals -d..d
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	found := false
+	for d := 0; d <= n+m && d <= maxEditDistance && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || k != d && v[k-1+offset] < v[k+1+offset] {
+				i = v[k+1+offset] // a line of b is added
+			} else {
+				i = v[k-1+offset] + 1 // a line of a is removed
+			}
+			j := i - k
+			for i < n && j < m && a[i] == b[j] {
+				i++
+				j++
+			}
+			v[k+offset] = i
+			if i >= n && j >= m {
+				found = true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	if !found {
+		var res []edit
+		for _, v := range x {
+			res = append(res, edit{'-', v})
+		}
+		for _, v := range y {
+			res = append(res, edit{'+', v})
+		}
+		return res
+	}
+	// walk back from the end, the edits are collected in the reverse order
+	var res []edit
+	i, j := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := i - j
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevI := at(prevK)
+		prevJ := prevI - prevK
+		for i > prevI && j > prevJ {
+			i--
+			j--
+			res = append(res, edit{' ', x[i]})
+		}
+		if prevK == k+1 {
+			j--
+			res = append(res, edit{'+', y[j]})
+		} else {
+			i--
+			res = append(res, edit{'-', x[i]})
+		}
+	}
+	for i > 0 && j > 0 {
+		i--
+		j--
+		res = append(res, edit{' ', x[i]})
+	}
+	for l, r := 0, len(res)-1; l < r; l, r = l+1, r-1 {
+		res[l], res[r] = res[r], res[l]
+	}
+	return res
+}
+
+// hunkRange the range of a hunk header, the start line is 1 based and it is the line before the hunk for an empty range
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines split b after every \n, the last line has no \n if b does not end with it
+func splitLines(b []byte) []string {
+	var res []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+		res = append(res, string(b[:i]))
+		b = b[i:]
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int, f string) string {
+		var sb strings.Builder
+		for i := 1; i <= n; i++ {
+			fmt.Fprintf(&sb, f+"\n", i)
+		}
+		return sb.String()
+	}
+	for _, v := range []struct {
+		name, a, b, want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"create", "", "a\nb\n", "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"delete", "a\n", "", "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n"},
+		{"insert", "a\nb\n", "a\nx\nb\n", "--- a\n+++ b\n@@ -1,2 +1,3 @@\n a\n+x\n b\n"},
+		{"replace", "a\nb\nc\n", "a\nx\ny\nc\n", "--- a\n+++ b\n@@ -1,3 +1,4 @@\n a\n-b\n+x\n+y\n c\n"},
+		{"context", lines(10, "%d"), strings.Replace(lines(10, "%d"), "5\n", "five\n", 1),
+			"--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"},
+		{"insert after the context", lines(8, "%d"), lines(8, "%d") + "9\n", "--- a\n+++ b\n@@ -6,3 +6,4 @@\n 6\n 7\n 8\n+9\n"},
+		// 6 same lines between the changes are in one hunk, 7 same lines split the hunks
+		{"merge", lines(10, "%d"), strings.NewReplacer("2\n", "x\n", "9\n", "y\n").Replace(lines(10, "%d")),
+			"--- a\n+++ b\n@@ -1,10 +1,10 @@\n 1\n-2\n+x\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+y\n 10\n"},
+		{"split", lines(11, "%d"), strings.NewReplacer("2\n", "x\n", "10\n", "y\n").Replace(lines(11, "%d")),
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n 4\n 5\n@@ -7,5 +7,5 @@\n 7\n 8\n 9\n-10\n+y\n 11\n"},
+		{"add newline", "a\nb", "a\nb\n", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"remove newline", "a\nb\n", "a\nb", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"change without newline", "a\nb", "a\nc", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+		{"same line without newline", "a\nb", "x\nb", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+x\n b\n\\ No newline at end of file\n"},
+	} {
+		if got := unifiedDiff([]byte(v.a), []byte(v.b), "a", "b"); got != v.want {
+			t.Errorf("%s: unifiedDiff =\n%s\nwant\n%s", v.name, got, v.want)
+		}
+	}
+
+	// the shortest edit script keeps the moved lines
+	got := unifiedDiff([]byte("a\nb\nc\nd\n"), []byte("b\nc\nd\na\n"), "a", "b")
+	if want := "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-a\n b\n c\n d\n+a\n"; got != want {
+		t.Errorf("unifiedDiff of the moved line =\n%s\nwant\n%s", got, want)
+	}
+
+	// the large files differ in every other line after the first one, more than maxEditDistance
+	a, b := lines(5000, "%d"), lines(5000, "%d")
+	b = strings.Replace(b, "\n", "\nx\n", -1)
+	got = unifiedDiff([]byte(a), []byte(b), "a", "b")
+	head := "--- a\n+++ b\n@@ -1,5000 +1,10000 @@\n"
+	if body := strings.TrimPrefix(got, head); body == got || strings.Count(body, "\n-") != 4999 || strings.Count(body, "\n+") != 9999 {
+		t.Errorf("unifiedDiff of the large files = %.100s", got)
+	}
+	b = strings.Replace(a, "2500\n", "x\n", 1)
+	got = unifiedDiff([]byte(a), []byte(b), "a", "b")
+	if want := "--- a\n+++ b\n@@ -2497,7 +2497,7 @@\n 2497\n 2498\n 2499\n-2500\n+x\n 2501\n 2502\n 2503\n"; got != want {
+		t.Errorf("unifiedDiff of the large files =\n%s\nwant\n%s", got, want)
+	}
+}
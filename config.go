@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/hongshengjie/crud/gen"
+)
+
+// config the project config crud.yaml in the working directory, every developer generates the same code by it.
+// The flags in the command line override it
+var config = gen.DefaultConfig()
+
+// loadConfig load the config file into config, the missing crud.yaml is not an error unless it is set by -config,
+// the values of the config are set to the flags which are not in the command line and then the flags are set to the config
+func loadConfig(file string, required bool) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil && (!os.IsNotExist(err) || required) {
+		log.Fatal(err)
+	}
+	if err == nil {
+		if err := yaml.UnmarshalStrict(b, config); err != nil {
+			log.Fatalf("%s: %v", file, err)
+		}
+		set := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		for name, v := range map[string]string{
+			"dialect":      config.Dialect,
+			"dsn":          config.DSN,
+			"tables":       strings.Join(config.DSNTables, ","),
+			"nullable":     config.Nullable,
+			"exactint":     strconv.FormatBool(config.ExactInt),
+			"service":      strconv.FormatBool(config.Generate.Service),
+			"http":         strconv.FormatBool(config.Generate.HTTP),
+			"reactgrommet": strconv.FormatBool(config.Generate.ReactGrommet),
+			"protowrapper": strconv.FormatBool(config.Generate.ProtoWrapper),
+			"protopkg":     config.Generate.ProtoPkg,
+		} {
+			if set[name] || v == "" || v == "false" {
+				continue
+			}
+			if err := flag.Set(name, v); err != nil {
+				log.Fatalf("%s: %s %v", file, name, err)
+			}
+		}
+	}
+	config.Dialect = dialect
+	config.DSN = dsn
+	config.DSNTables = nil
+	for _, v := range strings.Split(tables, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			config.DSNTables = append(config.DSNTables, v)
+		}
+	}
+	config.Nullable = nullable
+	config.ExactInt = exactint
+	config.Generate.Service = service
+	config.Generate.HTTP = http
+	config.Generate.ReactGrommet = reactgrommet
+	config.Generate.ProtoWrapper = protowrapper
+	config.Generate.ProtoPkg = protopkg
+}
@@ -0,0 +1,369 @@
+package gen
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/hongshengjie/crud/internal/model"
+)
+
+// DefaultDir default folder of the .sql files and the generated packages
+const DefaultDir = "crud"
+
+// Config settings of the generation, the command line loads it from crud.yaml
+//
+//	dialect: mysql
+//	inputs: [crud]
+//	output: crud
+//	nullable: ptr
+//	types:
+//	  tinyint(1): bool
+//	generate:
+//	  service: true
+//	  protopkg: api
+//	tables:
+//	  member:
+//	    name: Person
+//	    columns:
+//	      user_id: {name: UserID, json: userId}
+//	targets:
+//	  - template: templates/repo.tmpl
+//	    output: internal/repo/{{.PackageName}}.go
+type Config struct {
+	Dialect   string   `yaml:"dialect"`    // mysql or postgres, -dialect
+	DSN       string   `yaml:"dsn"`        // read the tables from information_schema instead of the .sql files, -dsn
+	DSNTables []string `yaml:"dsn_tables"` // tables read from the dsn, all tables by default, -tables
+	Inputs    []string `yaml:"inputs"`     // .sql files or folders, crud by default
+	Output    string   `yaml:"output"`     // folder of the generated packages and aa_client.go, crud by default
+	Nullable  string   `yaml:"nullable"`   // -nullable
+	ExactInt  bool     `yaml:"exactint"`   // -exactint
+	Package   struct {
+		Prefix string `yaml:"prefix"`
+		Suffix string `yaml:"suffix"`
+	} `yaml:"package"` // package name of the tables is prefix + lower case go struct name + suffix
+	Types    map[string]string `yaml:"types"` // go type of the column type or data type: tinyint(1): bool, json: map[string]interface{}
+	Generate struct {
+		Service      bool   `yaml:"service"`      // -service
+		HTTP         bool   `yaml:"http"`         // -http
+		ReactGrommet bool   `yaml:"reactgrommet"` // -reactgrommet
+		ProtoWrapper bool   `yaml:"protowrapper"` // -protowrapper
+		ProtoPkg     string `yaml:"protopkg"`     // -protopkg
+		ProtoDir     string `yaml:"proto_dir"`    // folder of the proto files, proto by default
+		ServiceDir   string `yaml:"service_dir"`  // folder of the service implementations, service by default
+		WebDir       string `yaml:"web_dir"`      // folder of the react pages, web/src/pages by default
+	} `yaml:"generate"`
+	Tables    map[string]*TableOverride `yaml:"tables"`    // settings of the tables by the table name
+	Templates string                    `yaml:"templates"` // folder of the templates which override the builtin templates of the same name: model.tmpl builder.tmpl
+	Targets   []*Target                 `yaml:"targets"`   // custom templates generated with the tables
+}
+
+// TableOverride settings of a table in the config, they are applied like the @crud: directives of the table comment
+type TableOverride struct {
+	Name     string                     `yaml:"name"`     // go struct name, @crud:name=
+	Package  string                     `yaml:"package"`  // package name, the lower case go struct name by default
+	Skip     bool                       `yaml:"skip"`     // @crud:skip
+	ReadOnly bool                       `yaml:"readonly"` // @crud:readonly
+	NoWhere  bool                       `yaml:"nowhere"`  // @crud:nowhere
+	Track    bool                       `yaml:"track"`    // @crud:track
+	Columns  map[string]*ColumnOverride `yaml:"columns"`  // settings of the columns by the column name
+}
+
+// ColumnOverride settings of a column in the config, they are applied like the @crud: directives of the column comment
+type ColumnOverride struct {
+	Name     string `yaml:"name"`     // go field name, @crud:name=
+	JSON     string `yaml:"json"`     // json tag, @crud:json=
+	Type     string `yaml:"type"`     // go type, the type other than the builtin types is marshaled by encoding/json like @type:
+	Skip     bool   `yaml:"skip"`     // @crud:skip
+	ReadOnly bool   `yaml:"readonly"` // @crud:readonly
+	NoWhere  bool   `yaml:"nowhere"`  // @crud:nowhere
+}
+
+// model converts the override to the settings applied by the parser
+func (o *TableOverride) model() *model.TableOverride {
+	if o == nil {
+		return nil
+	}
+	res := &model.TableOverride{Name: o.Name, Package: o.Package, Skip: o.Skip, ReadOnly: o.ReadOnly, NoWhere: o.NoWhere, Track: o.Track}
+	for name, c := range o.Columns {
+		if res.Columns == nil {
+			res.Columns = map[string]*model.ColumnOverride{}
+		}
+		// the empty column entry of yaml is nil
+		if c == nil {
+			c = &ColumnOverride{}
+		}
+		res.Columns[name] = &model.ColumnOverride{Name: c.Name, JSON: c.JSON, Type: c.Type, Skip: c.Skip, ReadOnly: c.ReadOnly, NoWhere: c.NoWhere}
+	}
+	return res
+}
+
+const (
+	// ScopeTable the target is executed with every *Table
+	ScopeTable = "table"
+	// ScopeSchema the target is executed once with []*Table
+	ScopeSchema = "schema"
+)
+
+// Target template generated for every table or once for all tables
+type Target struct {
+	Template string           `yaml:"template"` // template file, the name of the builtin template for the builtin targets
+	Scope    string           `yaml:"scope"`    // table: executed with every *Table, schema: executed once with []*Table, table by default
+	Output   string           `yaml:"output"`   // output file, a template executed with the same data: internal/repo/{{.PackageName}}.go
+	NoViews  bool             `yaml:"noviews"`  // the table scope template is not executed with the views
+	Text     string           `yaml:"-"`        // template text, the Template file is read when it is empty
+	Funcs    template.FuncMap `yaml:"-"`        // functions added to FuncMap for the template and the output
+}
+
+// AllTargets returns the targets of the builtin templates enabled by the config followed by the custom targets,
+// the builtin templates are overridden by the templates folder. They are model.go where.go builder.go of every table,
+// the proto service and react files when they are enabled and aa_client.go of the whole schema.
+// The templates have the function dialect which returns the dialect of the config, the schema may have no table
+func (c *Config) AllTargets() ([]*Target, error) {
+	c = c.withDefaults()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	funcs := template.FuncMap{"dialect": func() string { return c.Dialect }}
+	dir := filepath.Join(c.Output, "{{.PackageName}}")
+	builtin := []*Target{
+		{Template: "model.tmpl", Output: filepath.Join(dir, "model.go")},
+		{Template: "where.tmpl", Output: filepath.Join(dir, "where.go")},
+		{Template: "builder.tmpl", Output: filepath.Join(dir, "builder.go")},
+	}
+	if c.Generate.Service {
+		builtin = append(builtin,
+			&Target{Template: "proto.tmpl", Output: filepath.Join(c.Generate.ProtoDir, "{{.PackageName}}.api.proto")},
+			&Target{Template: "service.tmpl", Output: filepath.Join(c.Generate.ServiceDir, "{{.PackageName}}.service.go")})
+		// the react page edits the records, views are read only
+		if c.Generate.ReactGrommet {
+			builtin = append(builtin, &Target{Template: "react-grommet.tmpl", Output: filepath.Join(c.Generate.WebDir, "{{.PackageName}}.tsx"), NoViews: true})
+		}
+	}
+	if c.DSN != "" || c.WholeSchema() {
+		builtin = append(builtin, &Target{Template: "client.tmpl", Scope: ScopeSchema, Output: filepath.Join(c.Output, "aa_client.go")})
+	}
+	var res []*Target
+	for _, v := range builtin {
+		text, err := c.Template(v.Template)
+		if err != nil {
+			return nil, err
+		}
+		v.Text, v.Funcs = text, funcs
+		res = append(res, v)
+	}
+	for _, v := range c.Targets {
+		t := *v
+		t.Funcs = funcs
+		res = append(res, &t)
+	}
+	return res, nil
+}
+
+// DefaultConfig returns the config of the command line without crud.yaml and flags
+func DefaultConfig() *Config {
+	return (&Config{}).withDefaults()
+}
+
+// withDefaults returns a copy of the config in which the empty fields have the default values
+func (c *Config) withDefaults() *Config {
+	d := *c
+	if d.Dialect == "" {
+		d.Dialect = model.DialectMySQL
+	}
+	if len(d.Inputs) == 0 {
+		d.Inputs = []string{DefaultDir}
+	}
+	if d.Output == "" {
+		d.Output = DefaultDir
+	}
+	if d.Templates == "" {
+		d.Templates = "templates"
+	}
+	if d.Generate.ProtoDir == "" {
+		d.Generate.ProtoDir = "proto"
+	}
+	if d.Generate.ServiceDir == "" {
+		d.Generate.ServiceDir = "service"
+	}
+	if d.Generate.WebDir == "" {
+		d.Generate.WebDir = "web/src/pages"
+	}
+	return &d
+}
+
+// Validate reports the invalid dialect, nullable style and targets of the config
+func (c *Config) Validate() error {
+	if c.Nullable != model.NullStyleNone && c.Nullable != model.NullStyleSQL && c.Nullable != model.NullStylePtr {
+		return fmt.Errorf("nullable %s not right must be sql or ptr", c.Nullable)
+	}
+	if c.Dialect != "" && c.Dialect != model.DialectMySQL && c.Dialect != model.DialectPostgres {
+		return fmt.Errorf("dialect %s not right must be mysql or postgres", c.Dialect)
+	}
+	if c.DSN != "" && c.Dialect != "" && c.Dialect != model.DialectMySQL {
+		return fmt.Errorf("dsn only support mysql")
+	}
+	for i, t := range c.Targets {
+		if t.Template == "" || t.Output == "" || t.Scope != "" && t.Scope != ScopeTable && t.Scope != ScopeSchema {
+			return fmt.Errorf("targets[%d] needs template and output, the scope must be table or schema", i)
+		}
+	}
+	return nil
+}
+
+// WholeSchema reports whether the config reads all tables of the schema: every input is a folder
+// or the dsn reads all tables of the database. aa_client.go and the edges are generated for the whole schema
+func (c *Config) WholeSchema() bool {
+	c = c.withDefaults()
+	if c.DSN != "" {
+		return len(c.DSNTables) == 0
+	}
+	for _, v := range c.Inputs {
+		if info, err := os.Stat(v); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// Load parse the tables of the inputs or the dsn and apply the settings of the config to them,
+// the @crud:skip tables are not returned
+func Load(c *Config) ([]*Table, error) {
+	c = c.withDefaults()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	var tables []*Table
+	if c.DSN != "" {
+		var err error
+		if tables, err = ReadSchema(c.DSN, c.DSNTables); err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 {
+			return nil, fmt.Errorf("no table found in the database of the dsn")
+		}
+	} else {
+		for _, v := range c.Inputs {
+			t, err := ParseFiles(c.Dialect, v)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, t...)
+		}
+	}
+	var res []*Table
+	for _, t := range tables {
+		o := c.Tables[t.TableName]
+		if err := model.ApplyOverride(t, c.Types, o.model()); err != nil {
+			return nil, err
+		}
+		if t.Skip {
+			continue
+		}
+		if o == nil || o.Package == "" {
+			t.PackageName = c.Package.Prefix + t.PackageName + c.Package.Suffix
+		}
+		t.OutputDir = filepath.ToSlash(filepath.Clean(c.Output))
+		t.Protopkg = c.Generate.ProtoPkg
+		t.ProtoWrapper = c.Generate.ProtoWrapper
+		model.RemoveSkipped(t)
+		if c.ExactInt {
+			model.SetExactInt(t)
+		}
+		model.SetNullStyle(t, c.Nullable)
+		res = append(res, t)
+	}
+	if c.DSN != "" || c.WholeSchema() {
+		if err := model.BuildEdges(res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ParseFiles parse the tables and views of the .sql file or the .sql files in the folder, the tables are not changed by the config
+func ParseFiles(dialect, path string) ([]*Table, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		fs, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = nil
+		for _, v := range fs {
+			if !v.IsDir() && strings.HasSuffix(strings.ToLower(v.Name()), ".sql") {
+				files = append(files, filepath.Join(path, v.Name()))
+			}
+		}
+	}
+	relative, err := model.GetRelativePath()
+	if err != nil {
+		return nil, err
+	}
+	// the views of the mysql files select from the tables of all the files
+	schema := &model.MysqlSchema{}
+	var res []*Table
+	for _, v := range files {
+		b, err := ioutil.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		var n int
+		if dialect == model.DialectPostgres {
+			var tables []*Table
+			tables, err = model.ParsePostgres("", string(b), relative)
+			n = len(tables)
+			res = append(res, tables...)
+		} else {
+			n, err = schema.Parse(string(b))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", v, err)
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("%s: no CREATE TABLE statement", v)
+		}
+	}
+	if dialect == model.DialectPostgres {
+		return res, nil
+	}
+	return schema.Tables("", relative)
+}
+
+// ReadSchema read the tables from information_schema of the mysql dsn, all tables of the database when tables is empty
+func ReadSchema(dsn string, tables []string) ([]*Table, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBName == "" {
+		return nil, fmt.Errorf("dsn must contain the database name  user:pwd@tcp(127.0.0.1:3306)/test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	var names []string
+	for _, v := range tables {
+		if v = strings.TrimSpace(v); v != "" {
+			names = append(names, v)
+		}
+	}
+	relative, err := model.GetRelativePath()
+	if err != nil {
+		return nil, err
+	}
+	return model.SchemaTables(context.Background(), db, cfg.DBName, names, relative)
+}
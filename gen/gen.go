@@ -0,0 +1,173 @@
+// Package gen generates the crud code of the tables, it is the generator of the crud command line
+//
+//	tables, err := gen.Load(config)
+//	targets, err := config.AllTargets()
+//	files, err := gen.Generate(tables, targets)
+//	for _, v := range files {
+//		os.WriteFile(v.Path, v.Content, 0644)
+//	}
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/hongshengjie/crud/internal/model"
+	"github.com/hongshengjie/crud/internal/templates"
+)
+
+// Table the parsed table with the settings of the config, it is the data of the templates
+type Table = model.Table
+
+// the types reachable from Table, callers of Load and the custom templates name them by gen
+type (
+	Column     = model.Column
+	EnumValue  = model.EnumValue
+	Index      = model.Index
+	Check      = model.Check
+	ForeignKey = model.ForeignKey
+	Edge       = model.Edge
+	Finder     = model.Finder
+	Keyset     = model.Keyset
+)
+
+// File generated file
+type File struct {
+	Path    string // path relative to the working directory: crud/user/model.go
+	Content []byte // the go file is formatted
+}
+
+// FuncMap functions of the templates
+var FuncMap = template.FuncMap{
+	"sqltool":                        model.SQLTool,
+	"pktool":                         model.PKTool,
+	"columntool":                     model.ColumnTool,
+	"pkgargs":                        model.PackageArgs,
+	"param":                          model.GoParamName,
+	"isnumber":                       model.IsNumber,
+	"Incr":                           model.Incr,
+	"GoTypeToTypeScriptDefaultValue": model.GoTypeToTypeScriptDefaultValue,
+	"nullable":                       model.IsNullType,
+	"nullcheck":                      model.NullCheck,
+	"nullvalue":                      model.NullValue,
+	"nullassign":                     model.NullAssign,
+	"protowrapper":                   model.ProtoWrapperType,
+	"wrapperspb":                     model.ProtoWrapperFunc,
+	"fromproto":                      model.GoValue,
+	"toproto":                        model.ProtoValue,
+	"keyvalue":                       model.KeyValue,
+	"dbdefault":                      model.DatabaseDefault,
+	"notzero":                        model.NotZero,
+	"godefault":                      model.GoDefault,
+	"differs":                        model.Differs,
+	"camel":                          model.GoCamelCase,
+	"lowercamel":                     model.JSONCamelCase,
+	"snake":                          model.SnakeCase,
+	"plural":                         model.Plural,
+	"prototype":                      model.GoTypeToProtoType,
+	"lower":                          strings.ToLower,
+	"upper":                          strings.ToUpper,
+}
+
+// Template returns the template in the templates folder of the config which overrides the builtin template of the same name
+func (c *Config) Template(name string) (string, error) {
+	c = c.withDefaults()
+	b, err := ioutil.ReadFile(filepath.Join(c.Templates, name))
+	if os.IsNotExist(err) {
+		b, err = templates.FS.ReadFile(name)
+	}
+	return string(b), err
+}
+
+// Execute renders the template with the data, the go file is formatted
+func Execute(filename, tmpl string, data interface{}) ([]byte, error) {
+	return execute(filename, tmpl, nil, data)
+}
+
+// execute renders the template with the functions added to FuncMap
+func execute(filename, tmpl string, funcs template.FuncMap, data interface{}) ([]byte, error) {
+	tpl, err := template.New(filename).Funcs(FuncMap).Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	bs := bytes.NewBuffer(nil)
+	if err := tpl.Execute(bs, data); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(filename, ".go") {
+		return bs.Bytes(), nil
+	}
+	result, err := format.Source(bs.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return result, nil
+}
+
+// Generate renders the targets with the tables returned by Load, nothing is written to disk. The table scope targets
+// are rendered table by table, then the schema scope targets. Config.AllTargets returns the targets of the command line
+func Generate(tables []*Table, targets []*Target) ([]File, error) {
+	texts := make([]string, len(targets))
+	for i, t := range targets {
+		if t.Output == "" || t.Template == "" && t.Text == "" || t.Scope != "" && t.Scope != ScopeTable && t.Scope != ScopeSchema {
+			return nil, fmt.Errorf("targets[%d] needs template and output, the scope must be table or schema", i)
+		}
+		texts[i] = t.Text
+		if texts[i] == "" {
+			b, err := ioutil.ReadFile(t.Template)
+			if err != nil {
+				return nil, err
+			}
+			texts[i] = string(b)
+		}
+	}
+	g := &generator{}
+	for _, v := range tables {
+		for i, t := range targets {
+			if t.Scope != ScopeSchema && !(t.NoViews && v.IsView) {
+				g.target(t, texts[i], v)
+			}
+		}
+	}
+	for i, t := range targets {
+		if t.Scope == ScopeSchema {
+			g.target(t, texts[i], tables)
+		}
+	}
+	return g.files, g.err
+}
+
+// generator collects the rendered files, it stops at the first error
+type generator struct {
+	files []File
+	err   error
+}
+
+// target renders the template of the target with the data, the output file name is executed with the same data
+func (g *generator) target(t *Target, text string, data interface{}) {
+	if g.err != nil {
+		return
+	}
+	output, err := template.New(t.Output).Funcs(FuncMap).Funcs(t.Funcs).Parse(t.Output)
+	if err != nil {
+		g.err = err
+		return
+	}
+	filename := bytes.NewBuffer(nil)
+	if err := output.Execute(filename, data); err != nil {
+		g.err = err
+		return
+	}
+	content, err := execute(filename.String(), text, t.Funcs, data)
+	if err != nil {
+		g.err = fmt.Errorf("generate %s: %v", filename, err)
+		return
+	}
+	g.files = append(g.files, File{Path: filename.String(), Content: content})
+}
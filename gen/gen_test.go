@@ -0,0 +1,168 @@
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("user.sql", "CREATE TABLE `user` (`id` bigint NOT NULL AUTO_INCREMENT, `name` varchar(32) NOT NULL COMMENT 'nick name @crud:json=nick', PRIMARY KEY (`id`));")
+	write("orders.sql", "CREATE TABLE `orders` (`id` bigint NOT NULL AUTO_INCREMENT, `user_id` bigint NOT NULL, PRIMARY KEY (`id`),\n"+
+		"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`));\n"+
+		"CREATE TABLE `audit` (`id` bigint NOT NULL, PRIMARY KEY (`id`)) COMMENT '@crud:skip';")
+	write("names.tmpl", "package repo\n\nvar Names = []string{ {{- range .}}\"{{snake .GoTableName}}\", {{end}} }\n")
+	if err := os.Mkdir(filepath.Join(dir, "templates"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "templates", "where.tmpl"), []byte("package {{.PackageName}}\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Inputs: []string{dir}, Output: "db", Templates: filepath.Join(dir, "templates")}
+	c.Package.Suffix = "db"
+	c.Generate.Service = true
+	c.Targets = []*Target{{Template: filepath.Join(dir, "names.tmpl"), Scope: ScopeSchema, Output: "repo/names.go"}}
+	tables, err := Load(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].TableName != "orders" || tables[0].PackageName != "ordersdb" || len(tables[0].Edges) != 1 {
+		t.Fatalf("tables = %d %s", len(tables), tables[0].PackageName)
+	}
+	targets, err := c.AllTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Generate(tables, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	content := map[string]string{}
+	for _, v := range files {
+		paths = append(paths, filepath.ToSlash(v.Path))
+		content[filepath.ToSlash(v.Path)] = string(v.Content)
+	}
+	want := "db/ordersdb/model.go db/ordersdb/where.go db/ordersdb/builder.go proto/ordersdb.api.proto service/ordersdb.service.go " +
+		"db/userdb/model.go db/userdb/where.go db/userdb/builder.go proto/userdb.api.proto service/userdb.service.go db/aa_client.go repo/names.go"
+	if strings.Join(paths, " ") != want {
+		t.Fatalf("files = %s, want %s", strings.Join(paths, " "), want)
+	}
+	if content["db/userdb/where.go"] != "package userdb\n" {
+		t.Fatalf("where.go = %q", content["db/userdb/where.go"])
+	}
+	// the directives of the comment are not in the docs of the generated code
+	for _, v := range []string{"db/userdb/model.go", "proto/userdb.api.proto"} {
+		if !strings.Contains(content[v], "nick name\n") || strings.Contains(content[v], "@crud:") {
+			t.Fatalf("%s has the comment with the directives\n%s", v, content[v])
+		}
+	}
+	if !strings.Contains(content["db/aa_client.go"], "/db/ordersdb\"") {
+		t.Fatalf("aa_client.go does not import db/ordersdb")
+	}
+	if !strings.Contains(content["repo/names.go"], `[]string{"orders", "user"}`) {
+		t.Fatalf("names.go = %s", content["repo/names.go"])
+	}
+
+	for _, c := range []*Config{
+		{Inputs: []string{filepath.Join(dir, "missing")}},
+		{Inputs: []string{dir}, Nullable: "null"},
+		{Inputs: []string{dir}, Dialect: "oracle"},
+		{Inputs: []string{filepath.Join(dir, "names.tmpl")}},
+	} {
+		if _, err := Load(c); err == nil {
+			t.Errorf("Load(%+v) = nil, want error", c)
+		}
+	}
+	// the empty column entry of yaml is nil
+	o := &Config{Inputs: []string{filepath.Join(dir, "user.sql")}, Tables: map[string]*TableOverride{
+		"user": {Name: "Member", Package: "people", Columns: map[string]*ColumnOverride{"name": {Name: "Nick", JSON: "nick"}, "id": nil}},
+	}}
+	tables, err = Load(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := tables[0]; m.GoTableName != "Member" || m.PackageName != "people" || m.Fields[1].GoColumnName != "Nick" || m.Fields[1].JSONName != "nick" {
+		t.Fatalf("override = %s %s %s %s", m.GoTableName, m.PackageName, m.Fields[1].GoColumnName, m.Fields[1].JSONName)
+	}
+	o.Tables["user"].Columns["age"] = &ColumnOverride{Skip: true}
+	if _, err := Load(o); err == nil || !strings.Contains(err.Error(), "column age of the config is not exist") {
+		t.Errorf("Load of the unknown column = %v", err)
+	}
+	for _, v := range [][]*Target{
+		{{Template: "x.tmpl"}},
+		{{Output: "x.go"}},
+		{{Template: filepath.Join(dir, "missing.tmpl"), Output: "x.go"}},
+		{{Text: "{{.TableName}}", Output: "x.txt", Scope: "all"}},
+	} {
+		if _, err := Generate(tables, v); err == nil {
+			t.Errorf("Generate(%+v) = nil, want error", v[0])
+		}
+	}
+
+	// the targets of a library caller without the config, the views are not rendered by the NoViews target
+	view := *tables[0]
+	view.TableName, view.GoTableName, view.IsView = "user_view", "UserView", true
+	files, err = Generate(append(tables, &view), []*Target{
+		{Text: "{{.TableName}} {{kind}}", Output: "out/{{snake .GoTableName}}.txt", Funcs: map[string]interface{}{"kind": func() string { return "table" }}},
+		{Text: "{{.TableName}}", Output: "edit/{{snake .GoTableName}}.txt", NoViews: true},
+		{Text: "{{len .}}", Output: "out/count.txt", Scope: ScopeSchema},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = nil
+	for _, v := range files {
+		paths = append(paths, v.Path+":"+string(v.Content))
+	}
+	if want := "out/member.txt:user table edit/member.txt:user out/user_view.txt:user_view table out/count.txt:2"; strings.Join(paths, " ") != want {
+		t.Fatalf("files = %s, want %s", strings.Join(paths, " "), want)
+	}
+}
+
+func TestGenerateEmptySchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the folder created by crud init has no .sql file
+	for dialect, want := range map[string]string{"mysql": "xsql.NewMySQL(config)", "postgres": "postgres.New(config)"} {
+		c := &Config{Inputs: []string{dir}, Dialect: dialect}
+		tables, err := Load(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tables) != 0 {
+			t.Fatalf("tables = %d, want 0", len(tables))
+		}
+		targets, err := c.AllTargets()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := Generate(tables, targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 || files[0].Path != filepath.Join("crud", "aa_client.go") || !strings.Contains(string(files[0].Content), want) {
+			t.Fatalf("%s files = %v, want aa_client.go calls %s", dialect, files, want)
+		}
+		// the variables of the table clients are not declared without tables
+		if content := string(files[0].Content); strings.Contains(content, "master :=") || strings.Contains(content, "eq :=") {
+			t.Fatalf("aa_client.go declares unused variables\n%s", content)
+		}
+	}
+}
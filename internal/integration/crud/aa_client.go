@@ -0,0 +1,625 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hongshengjie/crud/internal/integration/crud/orders"
+	"github.com/hongshengjie/crud/internal/integration/crud/user"
+	"github.com/hongshengjie/crud/internal/integration/crud/userrole"
+	"github.com/hongshengjie/crud/xsql"
+)
+
+type Client struct {
+	config   *xsql.Config
+	db       *xsql.DB
+	Master   *ClientM
+	User     *UserClient
+	UserRole *UserRoleClient
+	Orders   *OrdersClient
+}
+
+type ClientM struct {
+	User     *UserClient
+	UserRole *UserRoleClient
+	Orders   *OrdersClient
+}
+
+func (c *Client) init() {
+	master := xsql.WithDialect(c.db.Master(), c.db.Dialect())
+	c.User = &UserClient{eq: c.db, config: c.config}
+	c.UserRole = &UserRoleClient{eq: c.db, config: c.config}
+	c.Orders = &OrdersClient{eq: c.db, config: c.config}
+	c.Master = &ClientM{
+		User:     &UserClient{eq: master, config: c.config},
+		UserRole: &UserRoleClient{eq: master, config: c.config},
+		Orders:   &OrdersClient{eq: master, config: c.config},
+	}
+}
+
+type Tx struct {
+	config   *xsql.Config
+	tx       *sql.Tx
+	dialect  string
+	User     *UserClient
+	UserRole *UserRoleClient
+	Orders   *OrdersClient
+}
+
+func (tx *Tx) init() {
+	eq := xsql.WithDialect(tx.tx, tx.dialect)
+	tx.User = &UserClient{eq: eq, config: tx.config}
+	tx.UserRole = &UserRoleClient{eq: eq, config: tx.config}
+	tx.Orders = &OrdersClient{eq: eq, config: tx.config}
+}
+
+func NewClient(config *xsql.Config) (*Client, error) {
+	db, err := xsql.NewMySQL(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithDB(config, db), nil
+}
+
+// NewClientWithDB create a client on the opened db, such as a sqlite db opened by sqlite.New of xsql/sqlite in unit tests
+func NewClientWithDB(config *xsql.Config, db *xsql.DB) *Client {
+	c := &Client{config: config, db: db}
+	c.init()
+	return c
+}
+
+func (c *Client) Begin(ctx context.Context) (*Tx, error) {
+	return c.BeginTx(ctx, nil)
+}
+
+func (c *Client) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := c.db.Master().BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tx{tx: tx, config: c.config, dialect: c.db.Dialect()}
+	t.init()
+	return t, nil
+}
+
+func (tx *Tx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+func (tx *Tx) Commit() error {
+	return tx.tx.Commit()
+}
+
+type UserClient struct {
+	eq     xsql.ExecQuerier
+	config *xsql.Config
+}
+
+// Find returns a UserQuery, the With methods of it load the edges of the results
+func (c *UserClient) Find() *UserQuery {
+	return &UserQuery{SelectBuilder: user.Find(c.eq).Timeout(c.config.QueryTimeout), client: c}
+}
+
+func (c *UserClient) Create() *user.InsertBuilder {
+	return user.Create(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *UserClient) Update() *user.UpdateBuilder {
+	return user.Update(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *UserClient) Delete() *user.DeleteBuilder {
+	return user.Delete(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *UserClient) FindByPK(ctx context.Context, id int64) (*user.User, error) {
+	return c.Find().SelectBuilder.Where(user.PrimaryKeyEQ(id)).One(ctx)
+}
+
+func (c *UserClient) UpdateByPK(id int64) *user.UpdateBuilder {
+	return c.Update().Where(user.PrimaryKeyEQ(id))
+}
+
+func (c *UserClient) DeleteByPK(ctx context.Context, id int64) (int64, error) {
+	return c.Delete().Where(user.PrimaryKeyEQ(id)).Exec(ctx)
+}
+
+// Save updates the changed columns of a by the primary key with the ExecTimeout
+func (c *UserClient) Save(ctx context.Context, a *user.User) (int64, error) {
+	_, ctx, cancel := xsql.Shrink(ctx, c.config.ExecTimeout)
+	defer cancel()
+	return a.Save(ctx, c.eq)
+}
+
+func (c *UserClient) GetByName(ctx context.Context, name string) (*user.User, error) {
+	return c.Find().SelectBuilder.Where(user.ByName(name)).One(ctx)
+}
+
+func (c *UserClient) FindByMtime(mtime time.Time) *user.SelectBuilder {
+	return c.Find().SelectBuilder.Where(user.ByMtime(mtime))
+}
+
+type UserRoleClient struct {
+	eq     xsql.ExecQuerier
+	config *xsql.Config
+}
+
+func (c *UserRoleClient) Find() *userrole.SelectBuilder {
+	return userrole.Find(c.eq).Timeout(c.config.QueryTimeout)
+}
+
+func (c *UserRoleClient) Create() *userrole.InsertBuilder {
+	return userrole.Create(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *UserRoleClient) Update() *userrole.UpdateBuilder {
+	return userrole.Update(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *UserRoleClient) Delete() *userrole.DeleteBuilder {
+	return userrole.Delete(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *UserRoleClient) FindByPK(ctx context.Context, userId int64, roleId int64) (*userrole.UserRole, error) {
+	return c.Find().Where(userrole.PrimaryKeyEQ(userId, roleId)).One(ctx)
+}
+
+func (c *UserRoleClient) UpdateByPK(userId int64, roleId int64) *userrole.UpdateBuilder {
+	return c.Update().Where(userrole.PrimaryKeyEQ(userId, roleId))
+}
+
+func (c *UserRoleClient) DeleteByPK(ctx context.Context, userId int64, roleId int64) (int64, error) {
+	return c.Delete().Where(userrole.PrimaryKeyEQ(userId, roleId)).Exec(ctx)
+}
+
+func (c *UserRoleClient) FindByUserId(userId int64) *userrole.SelectBuilder {
+	return c.Find().Where(userrole.ByUserId(userId))
+}
+
+type OrdersClient struct {
+	eq     xsql.ExecQuerier
+	config *xsql.Config
+}
+
+// Find returns a OrdersQuery, the With methods of it load the edges of the results
+func (c *OrdersClient) Find() *OrdersQuery {
+	return &OrdersQuery{SelectBuilder: orders.Find(c.eq).Timeout(c.config.QueryTimeout), client: c}
+}
+
+func (c *OrdersClient) Create() *orders.InsertBuilder {
+	return orders.Create(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *OrdersClient) Update() *orders.UpdateBuilder {
+	return orders.Update(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *OrdersClient) Delete() *orders.DeleteBuilder {
+	return orders.Delete(c.eq).Timeout(c.config.ExecTimeout)
+}
+
+func (c *OrdersClient) FindByPK(ctx context.Context, id int64) (*orders.Orders, error) {
+	return c.Find().SelectBuilder.Where(orders.PrimaryKeyEQ(id)).One(ctx)
+}
+
+func (c *OrdersClient) UpdateByPK(id int64) *orders.UpdateBuilder {
+	return c.Update().Where(orders.PrimaryKeyEQ(id))
+}
+
+func (c *OrdersClient) DeleteByPK(ctx context.Context, id int64) (int64, error) {
+	return c.Delete().Where(orders.PrimaryKeyEQ(id)).Exec(ctx)
+}
+
+func (c *OrdersClient) FindByUserId(userId int64) *orders.SelectBuilder {
+	return c.Find().SelectBuilder.Where(orders.ByUserId(userId))
+}
+
+// UserNode user.User with the edges loaded by UserQuery
+type UserNode struct {
+	*user.User
+	Edges  UserEdges `json:"edges"`
+	client *UserClient
+}
+
+// UserEdges edges of user loaded by the With methods of UserQuery, nil if not loaded
+type UserEdges struct {
+	// Orders orders.user_id references user.id
+	Orders []*OrdersNode `json:"orders,omitempty"`
+}
+
+// UserQuery the SelectBuilder of user returned by Find, the With methods load the edges of all results by one IN query per edge.
+// All One and Batches return the records with the edges, the other methods of the SelectBuilder read the records without the edges
+type UserQuery struct {
+	*user.SelectBuilder
+	client  *UserClient
+	loaders []func(context.Context, []*UserNode) error
+}
+
+// Timeout Timeout
+func (q *UserQuery) Timeout(t time.Duration) *UserQuery {
+	q.SelectBuilder.Timeout(t)
+	return q
+}
+
+// Select Select
+func (q *UserQuery) Select(columns ...string) *UserQuery {
+	q.SelectBuilder.Select(columns...)
+	return q
+}
+
+// Count Count
+func (q *UserQuery) Count(columns ...string) *UserQuery {
+	q.SelectBuilder.Count(columns...)
+	return q
+}
+
+// Where Where
+func (q *UserQuery) Where(p ...user.UserWhere) *UserQuery {
+	q.SelectBuilder.Where(p...)
+	return q
+}
+
+// WhereP WhereP
+func (q *UserQuery) WhereP(ps ...*xsql.Predicate) *UserQuery {
+	q.SelectBuilder.WhereP(ps...)
+	return q
+}
+
+// Offset Offset
+func (q *UserQuery) Offset(offset int32) *UserQuery {
+	q.SelectBuilder.Offset(offset)
+	return q
+}
+
+// Limit Limit
+func (q *UserQuery) Limit(limit int32) *UserQuery {
+	q.SelectBuilder.Limit(limit)
+	return q
+}
+
+// OrderDesc OrderDesc
+func (q *UserQuery) OrderDesc(field string) *UserQuery {
+	q.SelectBuilder.OrderDesc(field)
+	return q
+}
+
+// OrderAsc OrderAsc
+func (q *UserQuery) OrderAsc(field string) *UserQuery {
+	q.SelectBuilder.OrderAsc(field)
+	return q
+}
+
+// After After
+func (q *UserQuery) After(order xsql.Keyset, cursor string) *UserQuery {
+	q.SelectBuilder.After(order, cursor)
+	return q
+}
+
+// Before Before
+func (q *UserQuery) Before(order xsql.Keyset, cursor string) *UserQuery {
+	q.SelectBuilder.Before(order, cursor)
+	return q
+}
+
+// ForceIndex ForceIndex
+func (q *UserQuery) ForceIndex(indexName ...string) *UserQuery {
+	q.SelectBuilder.ForceIndex(indexName...)
+	return q
+}
+
+// GroupBy GroupBy
+func (q *UserQuery) GroupBy(fields ...string) *UserQuery {
+	q.SelectBuilder.GroupBy(fields...)
+	return q
+}
+
+// Having Having
+func (q *UserQuery) Having(p *xsql.Predicate) *UserQuery {
+	q.SelectBuilder.Having(p)
+	return q
+}
+
+// All return all results with the loaded edges
+func (q *UserQuery) All(ctx context.Context) ([]*UserNode, error) {
+	list, err := q.SelectBuilder.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.load(ctx, list)
+}
+
+// One return the first result with the loaded edges, sql.ErrNoRows if not found
+func (q *UserQuery) One(ctx context.Context) (*UserNode, error) {
+	q.SelectBuilder.Limit(1)
+	nodes, err := q.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return nodes[0], nil
+}
+
+// Batches walks the records like user.SelectBuilder.Batches, the edges of every batch are loaded before fn is called
+func (q *UserQuery) Batches(ctx context.Context, size int32, fn func([]*UserNode) error) error {
+	return q.SelectBuilder.Batches(ctx, size, func(list []*user.User) error {
+		nodes, err := q.load(ctx, list)
+		if err != nil {
+			return err
+		}
+		return fn(nodes)
+	})
+}
+
+func (q *UserQuery) load(ctx context.Context, list []*user.User) ([]*UserNode, error) {
+	nodes := make([]*UserNode, 0, len(list))
+	for _, v := range list {
+		nodes = append(nodes, &UserNode{User: v, client: q.client})
+	}
+	if len(nodes) == 0 {
+		return nodes, nil
+	}
+	for _, load := range q.loaders {
+		if err := load(ctx, nodes); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// WithOrders load the orders references the id of the results,
+// opts filter the orders or load the edges of them
+func (q *UserQuery) WithOrders(opts ...func(*OrdersQuery)) *UserQuery {
+	q.loaders = append(q.loaders, func(ctx context.Context, nodes []*UserNode) error {
+		byKey := map[int64][]*UserNode{}
+		keys := make([]interface{}, 0, len(nodes))
+		for _, v := range nodes {
+			v.Edges.Orders = []*OrdersNode{}
+			k := v.Id
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], v)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		rq := (&OrdersClient{eq: q.client.eq, config: q.client.config}).Find().WhereP(xsql.In(orders.UserId, keys...))
+		for _, opt := range opts {
+			opt(rq)
+		}
+		list, err := rq.All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range list {
+			if !(r.UserId != nil) {
+				continue
+			}
+			for _, v := range byKey[*r.UserId] {
+				v.Edges.Orders = append(v.Edges.Orders, r)
+			}
+		}
+		return nil
+	})
+	return q
+}
+
+// QueryOrders return a OrdersQuery of the orders references the id of a
+func (c *UserClient) QueryOrders(a *user.User) *OrdersQuery {
+	q := (&OrdersClient{eq: c.eq, config: c.config}).Find()
+	return q.WhereP(xsql.EQ(orders.UserId, a.Id))
+}
+
+// QueryOrders return a OrdersQuery of the orders references the id of n
+func (n *UserNode) QueryOrders() *OrdersQuery {
+	return n.client.QueryOrders(n.User)
+}
+
+// OrdersNode orders.Orders with the edges loaded by OrdersQuery
+type OrdersNode struct {
+	*orders.Orders
+	Edges  OrdersEdges `json:"edges"`
+	client *OrdersClient
+}
+
+// OrdersEdges edges of orders loaded by the With methods of OrdersQuery, nil if not loaded
+type OrdersEdges struct {
+	// User orders.user_id references user.id
+	User *UserNode `json:"user,omitempty"`
+}
+
+// OrdersQuery the SelectBuilder of orders returned by Find, the With methods load the edges of all results by one IN query per edge.
+// All One and Batches return the records with the edges, the other methods of the SelectBuilder read the records without the edges
+type OrdersQuery struct {
+	*orders.SelectBuilder
+	client  *OrdersClient
+	loaders []func(context.Context, []*OrdersNode) error
+}
+
+// Timeout Timeout
+func (q *OrdersQuery) Timeout(t time.Duration) *OrdersQuery {
+	q.SelectBuilder.Timeout(t)
+	return q
+}
+
+// Select Select
+func (q *OrdersQuery) Select(columns ...string) *OrdersQuery {
+	q.SelectBuilder.Select(columns...)
+	return q
+}
+
+// Count Count
+func (q *OrdersQuery) Count(columns ...string) *OrdersQuery {
+	q.SelectBuilder.Count(columns...)
+	return q
+}
+
+// Where Where
+func (q *OrdersQuery) Where(p ...orders.OrdersWhere) *OrdersQuery {
+	q.SelectBuilder.Where(p...)
+	return q
+}
+
+// WhereP WhereP
+func (q *OrdersQuery) WhereP(ps ...*xsql.Predicate) *OrdersQuery {
+	q.SelectBuilder.WhereP(ps...)
+	return q
+}
+
+// Offset Offset
+func (q *OrdersQuery) Offset(offset int32) *OrdersQuery {
+	q.SelectBuilder.Offset(offset)
+	return q
+}
+
+// Limit Limit
+func (q *OrdersQuery) Limit(limit int32) *OrdersQuery {
+	q.SelectBuilder.Limit(limit)
+	return q
+}
+
+// OrderDesc OrderDesc
+func (q *OrdersQuery) OrderDesc(field string) *OrdersQuery {
+	q.SelectBuilder.OrderDesc(field)
+	return q
+}
+
+// OrderAsc OrderAsc
+func (q *OrdersQuery) OrderAsc(field string) *OrdersQuery {
+	q.SelectBuilder.OrderAsc(field)
+	return q
+}
+
+// After After
+func (q *OrdersQuery) After(order xsql.Keyset, cursor string) *OrdersQuery {
+	q.SelectBuilder.After(order, cursor)
+	return q
+}
+
+// Before Before
+func (q *OrdersQuery) Before(order xsql.Keyset, cursor string) *OrdersQuery {
+	q.SelectBuilder.Before(order, cursor)
+	return q
+}
+
+// ForceIndex ForceIndex
+func (q *OrdersQuery) ForceIndex(indexName ...string) *OrdersQuery {
+	q.SelectBuilder.ForceIndex(indexName...)
+	return q
+}
+
+// GroupBy GroupBy
+func (q *OrdersQuery) GroupBy(fields ...string) *OrdersQuery {
+	q.SelectBuilder.GroupBy(fields...)
+	return q
+}
+
+// Having Having
+func (q *OrdersQuery) Having(p *xsql.Predicate) *OrdersQuery {
+	q.SelectBuilder.Having(p)
+	return q
+}
+
+// All return all results with the loaded edges
+func (q *OrdersQuery) All(ctx context.Context) ([]*OrdersNode, error) {
+	list, err := q.SelectBuilder.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.load(ctx, list)
+}
+
+// One return the first result with the loaded edges, sql.ErrNoRows if not found
+func (q *OrdersQuery) One(ctx context.Context) (*OrdersNode, error) {
+	q.SelectBuilder.Limit(1)
+	nodes, err := q.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return nodes[0], nil
+}
+
+// Batches walks the records like orders.SelectBuilder.Batches, the edges of every batch are loaded before fn is called
+func (q *OrdersQuery) Batches(ctx context.Context, size int32, fn func([]*OrdersNode) error) error {
+	return q.SelectBuilder.Batches(ctx, size, func(list []*orders.Orders) error {
+		nodes, err := q.load(ctx, list)
+		if err != nil {
+			return err
+		}
+		return fn(nodes)
+	})
+}
+
+func (q *OrdersQuery) load(ctx context.Context, list []*orders.Orders) ([]*OrdersNode, error) {
+	nodes := make([]*OrdersNode, 0, len(list))
+	for _, v := range list {
+		nodes = append(nodes, &OrdersNode{Orders: v, client: q.client})
+	}
+	if len(nodes) == 0 {
+		return nodes, nil
+	}
+	for _, load := range q.loaders {
+		if err := load(ctx, nodes); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// WithUser load the user referenced by the user_id of the results,
+// opts filter the user or load the edges of them
+func (q *OrdersQuery) WithUser(opts ...func(*UserQuery)) *OrdersQuery {
+	q.loaders = append(q.loaders, func(ctx context.Context, nodes []*OrdersNode) error {
+		byKey := map[int64][]*OrdersNode{}
+		keys := make([]interface{}, 0, len(nodes))
+		for _, v := range nodes {
+			if !(v.UserId != nil) {
+				continue
+			}
+			k := *v.UserId
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], v)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		rq := (&UserClient{eq: q.client.eq, config: q.client.config}).Find().WhereP(xsql.In(user.Id, keys...))
+		for _, opt := range opts {
+			opt(rq)
+		}
+		list, err := rq.All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range list {
+			for _, v := range byKey[r.Id] {
+				v.Edges.User = r
+			}
+		}
+		return nil
+	})
+	return q
+}
+
+// QueryUser return a UserQuery of the user referenced by the user_id of a, it finds nothing if the user_id is NULL
+func (c *OrdersClient) QueryUser(a *orders.Orders) *UserQuery {
+	q := (&UserClient{eq: c.eq, config: c.config}).Find()
+	if !(a.UserId != nil) {
+		// a NULL user_id references no user
+		return q.WhereP(xsql.False())
+	}
+	return q.WhereP(xsql.EQ(user.Id, *a.UserId))
+}
+
+// QueryUser return a UserQuery of the user referenced by the user_id of n
+func (n *OrdersNode) QueryUser() *UserQuery {
+	return n.client.QueryUser(n.Orders)
+}
@@ -0,0 +1,26 @@
+CREATE TABLE `user` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) NOT NULL,
+  `age` int NOT NULL DEFAULT '0',
+  `ctime` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `mtime` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`),
+  KEY `ix_mtime` (`mtime`)
+) ENGINE=InnoDB COMMENT='@crud:track';
+
+CREATE TABLE `user_role` (
+  `user_id` bigint NOT NULL,
+  `role_id` bigint NOT NULL,
+  `note` varchar(64) NOT NULL DEFAULT '',
+  PRIMARY KEY (`user_id`, `role_id`)
+) ENGINE=InnoDB;
+
+CREATE TABLE `orders` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` bigint DEFAULT NULL,
+  `amount` int NOT NULL DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `ix_user` (`user_id`),
+  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB;
@@ -0,0 +1,4 @@
+// Package integration runs the code generated from crud/schema.sql on sqlite, the tests check the generated code is up to date
+package integration
+
+//go:generate go run github.com/hongshengjie/crud -nullable ptr
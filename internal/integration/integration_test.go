@@ -0,0 +1,384 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongshengjie/crud/gen"
+	"github.com/hongshengjie/crud/internal/integration/crud"
+	"github.com/hongshengjie/crud/internal/integration/crud/orders"
+	"github.com/hongshengjie/crud/internal/integration/crud/user"
+	"github.com/hongshengjie/crud/internal/integration/crud/userrole"
+	"github.com/hongshengjie/crud/xsql"
+	"github.com/hongshengjie/crud/xsql/sqlite"
+)
+
+// the sqlite tables of crud/schema.sql
+var schema = []string{
+	"CREATE TABLE user (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE, age INTEGER NOT NULL DEFAULT 0, " +
+		"ctime DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, mtime DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+	"CREATE TABLE user_role (user_id INTEGER NOT NULL, role_id INTEGER NOT NULL, note TEXT NOT NULL DEFAULT '', PRIMARY KEY (user_id, role_id))",
+	"CREATE TABLE orders (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER REFERENCES user (id) ON DELETE SET NULL, amount INTEGER NOT NULL DEFAULT 0)",
+}
+
+func open(t *testing.T) (*xsql.DB, *crud.Client) {
+	t.Helper()
+	db, err := sqlite.New(&xsql.Config{DSN: "file::memory:", Active: 1, Idle: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Master().Close() })
+	for _, v := range schema {
+		if _, err := db.ExecContext(context.Background(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, crud.NewClientWithDB(&xsql.Config{QueryTimeout: time.Second, ExecTimeout: time.Second}, db)
+}
+
+func TestGenerated(t *testing.T) {
+	// the flags of go:generate
+	config := gen.DefaultConfig()
+	config.Nullable = "ptr"
+	tables, err := gen.Load(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := config.AllTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := gen.Generate(tables, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range files {
+		b, err := ioutil.ReadFile(v.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, v.Content) {
+			t.Errorf("%s is out of date, run go generate", v.Path)
+		}
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	ctx := context.Background()
+	_, c := open(t)
+	ctime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	// ctime is left to the database by a and c, only b sets it
+	a, b, d := &user.User{Name: "a", Age: 1}, &user.User{Name: "b", Age: 2, Ctime: ctime}, &user.User{Name: "d", Age: 3}
+	n, err := c.User.Create().SetUser(a, b, d).Save(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("Save = %d, %v", n, err)
+	}
+	if a.Id != 1 || b.Id != 2 || d.Id != 3 {
+		t.Fatalf("ids = %d %d %d", a.Id, b.Id, d.Id)
+	}
+	got, err := c.User.FindByPK(ctx, b.Id)
+	if err != nil || !got.Ctime.Equal(ctime) {
+		t.Fatalf("FindByPK = %+v, %v", got, err)
+	}
+	got, err = c.User.GetByName(ctx, "a")
+	if err != nil || got.Id != a.Id || got.Ctime.IsZero() {
+		t.Fatalf("GetByName = %+v, %v", got, err)
+	}
+
+	if _, err := c.User.Create().SetUser(&user.User{Id: a.Id, Name: "a", Age: 10}).Upsert(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.User.Create().SetUser(&user.User{Name: "b", Age: 20}).Ignore(ctx); err != nil || n != 0 {
+		t.Fatalf("Ignore = %d, %v", n, err)
+	}
+	if n, err := c.User.UpdateByPK(d.Id).AddAge(5).Save(ctx); err != nil || n != 1 {
+		t.Fatalf("Update = %d, %v", n, err)
+	}
+	var ages []int64
+	if err := c.User.Find().Select(user.Age).OrderAsc(user.Id).Slice(ctx, &ages); err != nil {
+		t.Fatal(err)
+	}
+	if len(ages) != 3 || ages[0] != 10 || ages[1] != 2 || ages[2] != 8 {
+		t.Fatalf("ages = %v", ages)
+	}
+	if n, err := c.User.DeleteByPK(ctx, b.Id); err != nil || n != 1 {
+		t.Fatalf("DeleteByPK = %d, %v", n, err)
+	}
+	if n, err := c.User.Find().Count().Int64(ctx); err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v", n, err)
+	}
+
+	r := &userrole.UserRole{UserId: a.Id, RoleId: 1}
+	if _, err := c.UserRole.Create().SetUserRole(r).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.UserRole.FindByPK(ctx, a.Id, 1); err != nil || got.Note != "" {
+		t.Fatalf("FindByPK = %+v, %v", got, err)
+	}
+}
+
+// recorder records the statements executed on the db
+type recorder struct {
+	*xsql.DB
+	execs   []string
+	queries []string
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.execs = append(r.execs, query)
+	return r.DB.ExecContext(ctx, query, args...)
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	r.queries = append(r.queries, query)
+	return r.DB.QueryContext(ctx, query, args...)
+}
+
+func TestTrack(t *testing.T) {
+	ctx := context.Background()
+	db, c := open(t)
+	if _, err := c.User.Create().SetUser(&user.User{Name: "a", Age: 1}).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	u, err := c.User.FindByPK(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{DB: db}
+	if n, err := u.SetAge(1).Save(ctx, r); err != nil || n != 0 || len(r.execs) != 0 || len(u.ChangedColumns()) != 0 {
+		t.Fatalf("unchanged Save = %d, %v, execs %v", n, err, r.execs)
+	}
+	u.SetName("b").SetAge(2)
+	if got := strings.Join(u.ChangedColumns(), " "); got != "name age" {
+		t.Fatalf("ChangedColumns = %s", got)
+	}
+	if n, err := u.Save(ctx, r); err != nil || n != 1 {
+		t.Fatalf("Save = %d, %v", n, err)
+	}
+	if len(r.execs) != 1 || r.execs[0] != "UPDATE `user` SET `name` = ?, `age` = ? WHERE `id` = ?" {
+		t.Fatalf("execs = %q", r.execs)
+	}
+	if len(u.ChangedColumns()) != 0 {
+		t.Fatalf("changes are not cleared: %v", u.ChangedColumns())
+	}
+	if n, err := u.Save(ctx, r); err != nil || n != 0 || len(r.execs) != 1 {
+		t.Fatalf("Save after Save = %d, %v, execs %q", n, err, r.execs)
+	}
+	got, err := c.User.FindByPK(ctx, 1)
+	if err != nil || got.Name != "b" || got.Age != 2 {
+		t.Fatalf("FindByPK = %+v, %v", got, err)
+	}
+
+	u.SetAge(3).ClearChanges()
+	if n, err := c.User.Save(ctx, u); err != nil || n != 0 {
+		t.Fatalf("Save of the cleared changes = %d, %v", n, err)
+	}
+	if n, err := c.User.Save(ctx, u.SetAge(4)); err != nil || n != 1 {
+		t.Fatalf("client Save = %d, %v", n, err)
+	}
+	if got, err := c.User.FindByPK(ctx, 1); err != nil || got.Age != 4 {
+		t.Fatalf("FindByPK = %+v, %v", got, err)
+	}
+}
+
+func TestIter(t *testing.T) {
+	ctx := context.Background()
+	_, c := open(t)
+	for _, v := range []string{"a", "b", "c", "d"} {
+		if _, err := c.User.Create().SetUser(&user.User{Name: v}).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the pool has one connection, the query after the iteration waits for it until the timeout if the rows are not closed
+	count := func() {
+		t.Helper()
+		if n, err := c.User.Find().Count().Int64(ctx); err != nil || n != 4 {
+			t.Fatalf("Count after the iteration = %d, %v", n, err)
+		}
+	}
+	var names []string
+	err := c.User.Find().OrderAsc(user.Id).Each(ctx, func(a *user.User) error {
+		names = append(names, a.Name)
+		if len(names) == 2 {
+			return xsql.ErrStop
+		}
+		return nil
+	})
+	if err != nil || strings.Join(names, " ") != "a b" {
+		t.Fatalf("Each = %v, %v", names, err)
+	}
+	count()
+	errFail := errors.New("fail")
+	names = nil
+	err = c.User.Find().Where(user.NameNEQ("a")).OrderDesc(user.Id).Each(ctx, func(a *user.User) error {
+		names = append(names, a.Name)
+		return errFail
+	})
+	if err != errFail || strings.Join(names, " ") != "d" {
+		t.Fatalf("Each = %v, %v, want the error of fn", names, err)
+	}
+	count()
+
+	it, err := c.User.Find().Select(user.Id, user.Name).OrderAsc(user.Id).Iter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.Next() || it.Value().Name != "a" || it.Value().Age != 0 {
+		t.Fatalf("Next = %+v, %v", it.Value(), it.Err())
+	}
+	first := it.Value()
+	if !it.Next() || it.Value() == first || first.Name != "a" {
+		t.Fatalf("every record is a new one")
+	}
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	it.Close()
+	if it.Next() {
+		t.Fatalf("Next after Close = true")
+	}
+	count()
+	if _, err := c.User.Find().Select("nope").Iter(ctx); err == nil {
+		t.Fatalf("Iter of the unknown column = nil, want error")
+	}
+}
+
+func TestBatches(t *testing.T) {
+	ctx := context.Background()
+	db, c := open(t)
+	// inserted out of the primary key order, the batch boundaries fall inside and across the user_id
+	for _, v := range [][2]int64{{2, 1}, {1, 3}, {1, 1}, {3, 1}, {2, 3}, {1, 2}, {2, 2}} {
+		if _, err := c.UserRole.Create().SetUserRole(&userrole.UserRole{UserId: v[0], RoleId: v[1]}).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &recorder{DB: db}
+	var batches []string
+	err := userrole.Find(r).Offset(1).Limit(1).OrderDesc(userrole.Note).Batches(ctx, 2, func(list []*userrole.UserRole) error {
+		var keys []string
+		for _, v := range list {
+			keys = append(keys, fmt.Sprintf("%d-%d", v.UserId, v.RoleId))
+		}
+		batches = append(batches, strings.Join(keys, ","))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(batches, " "); got != "1-1,1-2 1-3,2-1 2-2,2-3 3-1" {
+		t.Fatalf("batches = %s", got)
+	}
+	want := []string{
+		"SELECT `user_id`, `role_id`, `note` FROM `user_role` ORDER BY `user_id` ASC, `role_id` ASC LIMIT 2 OFFSET 0",
+		"SELECT `user_id`, `role_id`, `note` FROM `user_role` WHERE (`user_id`, `role_id`) > (?, ?) ORDER BY `user_id` ASC, `role_id` ASC LIMIT 2 OFFSET 0",
+	}
+	if len(r.queries) != 4 || r.queries[0] != want[0] || r.queries[1] != want[1] || r.queries[3] != want[1] {
+		t.Fatalf("queries = %q", r.queries)
+	}
+
+	// the conditions of the query are kept, fn stops the walk
+	batches = nil
+	err = c.UserRole.Find().Where(userrole.RoleIdNEQ(2)).Batches(ctx, 2, func(list []*userrole.UserRole) error {
+		batches = append(batches, fmt.Sprintf("%d-%d", list[len(list)-1].UserId, list[len(list)-1].RoleId))
+		if len(batches) == 2 {
+			return xsql.ErrStop
+		}
+		return nil
+	})
+	if err != nil || strings.Join(batches, " ") != "1-3 2-3" {
+		t.Fatalf("Batches = %v, %v", batches, err)
+	}
+	errFail := errors.New("fail")
+	if err := c.UserRole.Find().Batches(ctx, 3, func([]*userrole.UserRole) error { return errFail }); err != errFail {
+		t.Fatalf("Batches = %v, want the error of fn", err)
+	}
+	if err := c.UserRole.Find().Select(userrole.UserId).Batches(ctx, 3, func([]*userrole.UserRole) error { return nil }); err == nil {
+		t.Fatalf("Batches without role_id = nil, want error")
+	}
+	if err := c.UserRole.Find().Batches(ctx, 0, func([]*userrole.UserRole) error { return nil }); err == nil {
+		t.Fatalf("Batches of size 0 = nil, want error")
+	}
+	// the walk over the empty result calls no fn
+	if err := c.UserRole.Find().Where(userrole.UserIdGT(3)).Batches(ctx, 2, func([]*userrole.UserRole) error { return errFail }); err != nil {
+		t.Fatalf("Batches of no record = %v", err)
+	}
+}
+
+func TestEdges(t *testing.T) {
+	ctx := context.Background()
+	_, c := open(t)
+	a, b := &user.User{Name: "a"}, &user.User{Name: "b"}
+	if _, err := c.User.Create().SetUser(a, b).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the order 3 has no user
+	for _, v := range []*orders.Orders{{UserId: &a.Id, Amount: 1}, {UserId: &a.Id, Amount: 2}, {Amount: 3}} {
+		if _, err := c.Orders.Create().SetOrders(v).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	amounts := func(list []*crud.OrdersNode) string {
+		var s []string
+		for _, v := range list {
+			s = append(s, fmt.Sprint(v.Amount))
+		}
+		return strings.Join(s, " ")
+	}
+
+	users, err := c.User.Find().OrderAsc(user.Id).WithOrders(func(q *crud.OrdersQuery) { q.OrderDesc(orders.Amount) }).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || amounts(users[0].Edges.Orders) != "2 1" || users[1].Edges.Orders == nil || len(users[1].Edges.Orders) != 0 {
+		t.Fatalf("WithOrders = %+v", users)
+	}
+	list, err := c.Orders.Find().OrderAsc(orders.Id).WithUser().All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Edges.User == nil || list[0].Edges.User.Name != "a" || list[1].Edges.User != list[0].Edges.User || list[2].Edges.User != nil {
+		t.Fatalf("WithUser = %+v", list)
+	}
+	if got, err := c.Orders.Find().Where(orders.AmountEQ(2)).WithUser(func(q *crud.UserQuery) { q.WithOrders() }).One(ctx); err != nil ||
+		got.Edges.User == nil || amounts(got.Edges.User.Edges.Orders) != "1 2" {
+		t.Fatalf("nested WithUser = %+v, %v", got, err)
+	}
+	var batches []string
+	err = c.Orders.Find().WithUser().Batches(ctx, 2, func(list []*crud.OrdersNode) error {
+		for _, v := range list {
+			if v.Edges.User != nil {
+				batches = append(batches, v.Edges.User.Name)
+			} else {
+				batches = append(batches, "-")
+			}
+		}
+		return nil
+	})
+	if err != nil || strings.Join(batches, " ") != "a a -" {
+		t.Fatalf("Batches = %v, %v", batches, err)
+	}
+
+	if n, err := users[0].QueryOrders().Count().Int64(ctx); err != nil || n != 2 {
+		t.Fatalf("QueryOrders = %d, %v", n, err)
+	}
+	if got, err := list[0].QueryUser().One(ctx); err != nil || got.Name != "a" {
+		t.Fatalf("QueryUser = %+v, %v", got, err)
+	}
+	// the NULL user_id finds no user instead of comparing user.id = NULL
+	if _, err := list[2].QueryUser().One(ctx); err != sql.ErrNoRows {
+		t.Fatalf("QueryUser of NULL user_id = %v, want sql.ErrNoRows", err)
+	}
+	if n, err := c.Orders.QueryUser(&orders.Orders{}).Count().Int64(ctx); err != nil || n != 0 {
+		t.Fatalf("QueryUser of NULL user_id count = %d, %v", n, err)
+	}
+	if got, err := c.User.FindByPK(ctx, b.Id); err != nil || got.Name != "b" {
+		t.Fatalf("FindByPK = %+v, %v", got, err)
+	}
+}
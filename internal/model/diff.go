@@ -0,0 +1,629 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffTables returns the statements which migrate the current schema to the desired schema in order:
+// renamed tables, created tables, then dropped foreign keys, checks and indexes, added columns, modified columns,
+// dropped columns, added indexes and checks and the table comment of every changed table, the added foreign keys
+// after all the tables are created, and dropped tables at last when dropTables is true.
+// The created tables are ordered after the created tables they reference.
+// renames maps the old table name to the new table name, the columns are not renamed.
+// The statements use the dialect of the desired tables and have no trailing semicolon, views are ignored.
+func DiffTables(current, desired []*Table, renames map[string]string, dropTables bool) []string {
+	current, desired = baseTables(current), baseTables(desired)
+	var stmts []string
+	cur := map[string]*Table{}
+	for _, v := range current {
+		cur[v.TableName] = v
+	}
+	want := map[string]*Table{}
+	for _, v := range desired {
+		want[v.TableName] = v
+	}
+	var olds []string
+	for k := range renames {
+		olds = append(olds, k)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		t, ok := cur[old]
+		to := want[renames[old]]
+		if !ok || to == nil || cur[to.TableName] != nil {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s RENAME TO %s", quoteIdent(to, old), quoteIdent(to, to.TableName)))
+		delete(cur, old)
+		cur[to.TableName] = t
+	}
+	var created, addForeignKeys []string
+	for _, t := range desired {
+		if c, ok := cur[t.TableName]; ok {
+			alter, fks := alterTable(c, t)
+			stmts = append(stmts, alter...)
+			addForeignKeys = append(addForeignKeys, fks...)
+			continue
+		}
+		created = append(created, t.TableName)
+	}
+	for _, name := range referencedFirst(created, want) {
+		stmts = append(stmts, CreateTable(want[name])...)
+	}
+	stmts = append(stmts, addForeignKeys...)
+	if dropTables {
+		for _, t := range current {
+			if c, ok := cur[t.TableName]; ok && c == t && want[t.TableName] == nil {
+				stmts = append(stmts, "DROP TABLE "+quoteIdent(t, t.TableName))
+			}
+		}
+	}
+	return stmts
+}
+
+// baseTables returns the tables which are not views
+func baseTables(tables []*Table) []*Table {
+	var res []*Table
+	for _, v := range tables {
+		if !v.IsView {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// referencedFirst orders the names of the created tables, the table referenced by the foreign keys of the others is
+// created before them, the tables referencing each other keep the order
+func referencedFirst(names []string, tables map[string]*Table) []string {
+	var res []string
+	done := map[string]bool{}
+	for len(res) < len(names) {
+		n := len(res)
+		for _, name := range names {
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, fk := range tables[name].ForeignKeys {
+				if ref := fk.RefTable; ref != name && tables[ref] != nil && !done[ref] && contains(names, ref) {
+					ready = false
+				}
+			}
+			if ready {
+				done[name] = true
+				res = append(res, name)
+			}
+		}
+		if len(res) == n {
+			for _, name := range names {
+				if !done[name] {
+					done[name] = true
+					res = append(res, name)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// CreateTable returns the CREATE TABLE statement of the table,
+// postgres indexes, table comment and column comments are created by the following statements
+func CreateTable(t *Table) []string {
+	var defs, after []string
+	for _, c := range t.Fields {
+		defs = append(defs, "  "+columnDefinition(t, c))
+	}
+	for _, idx := range t.Indexes {
+		switch {
+		case idx.Primary:
+			defs = append(defs, "  PRIMARY KEY "+indexColumns(t, idx))
+		case t.Dialect == DialectPostgres:
+			after = append(after, createIndex(t, idx))
+		case idx.Fulltext:
+			defs = append(defs, fmt.Sprintf("  FULLTEXT KEY %s %s", quoteIdent(t, idx.Name), indexColumns(t, idx)))
+		case idx.Unique:
+			defs = append(defs, fmt.Sprintf("  UNIQUE KEY %s %s", quoteIdent(t, idx.Name), indexColumns(t, idx)))
+		default:
+			defs = append(defs, fmt.Sprintf("  KEY %s %s", quoteIdent(t, idx.Name), indexColumns(t, idx)))
+		}
+	}
+	for _, v := range t.ForeignKeys {
+		defs = append(defs, "  "+foreignKeyDefinition(t, v))
+	}
+	for _, v := range t.Checks {
+		defs = append(defs, "  "+checkDefinition(t, v))
+	}
+	if t.Dialect == DialectPostgres {
+		if t.TableComment != "" {
+			after = append(after, commentTable(t))
+		}
+		for _, c := range t.Fields {
+			if c.ColumnComment != "" {
+				after = append(after, commentColumn(t, c))
+			}
+		}
+	}
+	stmt := fmt.Sprintf("CREATE TABLE %s (\n%s\n)", quoteIdent(t, t.TableName), strings.Join(defs, ",\n"))
+	if t.Dialect != DialectPostgres && t.TableComment != "" {
+		stmt += " COMMENT=" + quoteLiteral(t, t.TableComment)
+	}
+	return append([]string{stmt}, after...)
+}
+
+// alterTable returns the statements migrate the table from to the table to and the statements add the foreign keys
+func alterTable(from, to *Table) ([]string, []string) {
+	var dropForeignKeys, dropChecks, dropIndexes, addColumns, modifyColumns, dropColumns, addIndexes, addChecks, addForeignKeys []string
+	table := "ALTER TABLE " + quoteIdent(to, to.TableName)
+	for _, fk := range from.ForeignKeys {
+		if findForeignKey(to, fk) == nil {
+			dropForeignKeys = append(dropForeignKeys, dropConstraint(to, "FOREIGN KEY", fk.Name, foreignKeyDefinition(to, fk)))
+		}
+	}
+	for _, fk := range to.ForeignKeys {
+		if findForeignKey(from, fk) == nil {
+			addForeignKeys = append(addForeignKeys, table+" ADD "+foreignKeyDefinition(to, fk))
+		}
+	}
+	// the checks of the added and dropped columns are in the column definitions
+	fromChecks, toChecks := tableChecks(from, to), tableChecks(to, from)
+	for _, c := range fromChecks {
+		if findCheck(toChecks, c) == nil {
+			dropChecks = append(dropChecks, dropConstraint(to, "CHECK", c.Name, checkDefinition(to, c)))
+		}
+	}
+	for _, c := range toChecks {
+		if findCheck(fromChecks, c) == nil {
+			addChecks = append(addChecks, table+" ADD "+checkDefinition(to, c))
+		}
+	}
+	for _, idx := range from.Indexes {
+		v := findIndex(to, idx)
+		if v == nil && foreignKeyIndex(to, idx) {
+			// mysql creates the index of the foreign key, it is dropped with the foreign key
+			continue
+		}
+		if v == nil || !sameIndex(v, idx) {
+			dropIndexes = append(dropIndexes, dropIndex(to, idx))
+		}
+	}
+	for _, idx := range to.Indexes {
+		if v := findIndex(from, idx); v == nil || !sameIndex(v, idx) {
+			addIndexes = append(addIndexes, addIndex(to, idx))
+		}
+	}
+	for i, c := range to.Fields {
+		old := findColumn(from, c.ColumnName)
+		switch {
+		case old != nil && to.Dialect == DialectPostgres && !sameGeneration(old, c):
+			// postgres can not change the generation expression
+			modifyColumns = append(modifyColumns, table+" DROP COLUMN "+quoteIdent(to, old.ColumnName), table+" ADD COLUMN "+columnDefinition(to, c))
+		case old == nil:
+			stmt := table + " ADD COLUMN " + columnDefinition(to, c)
+			if to.Dialect != DialectPostgres {
+				// keep the column order
+				if i == 0 {
+					stmt += " FIRST"
+				} else {
+					stmt += " AFTER " + quoteIdent(to, to.Fields[i-1].ColumnName)
+				}
+			}
+			addColumns = append(addColumns, stmt)
+			if to.Dialect == DialectPostgres && c.ColumnComment != "" {
+				addColumns = append(addColumns, commentColumn(to, c))
+			}
+		case !sameColumn(old, c):
+			modifyColumns = append(modifyColumns, modifyColumn(to, old, c)...)
+		}
+	}
+	for _, c := range from.Fields {
+		if findColumn(to, c.ColumnName) == nil {
+			dropColumns = append(dropColumns, table+" DROP COLUMN "+quoteIdent(to, c.ColumnName))
+		}
+	}
+	if from.TableComment != to.TableComment {
+		if to.Dialect == DialectPostgres {
+			addChecks = append(addChecks, commentTable(to))
+		} else {
+			addChecks = append(addChecks, table+" COMMENT = "+quoteLiteral(to, to.TableComment))
+		}
+	}
+	var stmts []string
+	for _, v := range [][]string{dropForeignKeys, dropChecks, dropIndexes, addColumns, modifyColumns, dropColumns, addIndexes, addChecks} {
+		stmts = append(stmts, v...)
+	}
+	return stmts, addForeignKeys
+}
+
+// dropConstraint the unnamed constraint has a name generated by the database, it is left as a comment
+func dropConstraint(t *Table, kind, name, definition string) string {
+	table := "ALTER TABLE " + quoteIdent(t, t.TableName)
+	switch {
+	case name == "":
+		return fmt.Sprintf("-- %s DROP the unnamed %s by the name generated by the database", table, definition)
+	case t.Dialect == DialectPostgres:
+		return table + " DROP CONSTRAINT " + quoteIdent(t, name)
+	}
+	return table + " DROP " + kind + " " + quoteIdent(t, name)
+}
+
+// tableChecks returns the checks of the table and its columns, the checks of the columns not in other are excluded
+func tableChecks(t, other *Table) []*Check {
+	res := append([]*Check(nil), t.Checks...)
+	for _, c := range t.Fields {
+		if findColumn(other, c.ColumnName) != nil {
+			res = append(res, c.Checks...)
+		}
+	}
+	return res
+}
+
+// findCheck the checks are the same by the expression and the name when both are named,
+// information_schema shows the generated name of the unnamed check
+func findCheck(checks []*Check, c *Check) *Check {
+	for _, v := range checks {
+		if (v.Name == "" || c.Name == "" || strings.EqualFold(v.Name, c.Name)) &&
+			v.NotEnforced == c.NotEnforced && normalizeCheck(v.Expression) == normalizeCheck(c.Expression) {
+			return v
+		}
+	}
+	return nil
+}
+
+// normalizeCheck information_schema shows the check expression in parentheses
+func normalizeCheck(expr string) string {
+	expr = normalizeExpression(expr)
+	for strings.HasPrefix(expr, "(") && closingParen(expr) == len(expr)-1 {
+		expr = expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+// closingParen returns the index of the parenthesis closing the first one
+func closingParen(s string) int {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// findForeignKey the foreign keys are the same by the columns, the references, the actions and the name when both are named
+func findForeignKey(t *Table, fk *ForeignKey) *ForeignKey {
+	for _, v := range t.ForeignKeys {
+		if (v.Name == "" || fk.Name == "" || strings.EqualFold(v.Name, fk.Name)) && sameForeignKey(t, v, fk) {
+			return v
+		}
+	}
+	return nil
+}
+
+// sameForeignKey the empty referenced columns of postgres are the primary key and match any columns,
+// the changed action drops and adds the foreign key
+func sameForeignKey(t *Table, a, b *ForeignKey) bool {
+	if !strings.EqualFold(a.RefTable, b.RefTable) || len(a.Columns) != len(b.Columns) ||
+		!sameAction(t, a.OnDelete, b.OnDelete) || !sameAction(t, a.OnUpdate, b.OnUpdate) {
+		return false
+	}
+	for i := range a.Columns {
+		if !strings.EqualFold(a.Columns[i].ColumnName, b.Columns[i].ColumnName) {
+			return false
+		}
+	}
+	if len(a.RefColumns) == 0 || len(b.RefColumns) == 0 {
+		return true
+	}
+	return strings.EqualFold(strings.Join(a.RefColumns, ","), strings.Join(b.RefColumns, ","))
+}
+
+// sameAction the missing action is NO ACTION, RESTRICT is the same as NO ACTION in mysql
+func sameAction(t *Table, a, b string) bool {
+	normalize := func(action string) string {
+		if action == "" || action == "RESTRICT" && t.Dialect != DialectPostgres {
+			return "NO ACTION"
+		}
+		return action
+	}
+	return normalize(a) == normalize(b)
+}
+
+// foreignKeyIndex reports whether the index is the one mysql creates for the foreign key of the same name
+func foreignKeyIndex(t *Table, idx *Index) bool {
+	for _, fk := range t.ForeignKeys {
+		if t.Dialect != DialectPostgres && !idx.Primary && strings.EqualFold(fk.Name, idx.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// modifyColumn mysql redefine the whole column, postgres alter the changed attributes
+func modifyColumn(t *Table, from, to *Column) []string {
+	table := "ALTER TABLE " + quoteIdent(t, t.TableName)
+	if t.Dialect != DialectPostgres {
+		return []string{table + " MODIFY COLUMN " + columnDefinition(t, to)}
+	}
+	column := table + " ALTER COLUMN " + quoteIdent(t, to.ColumnName)
+	var stmts []string
+	if !sameColumnType(from.ColumnType, to.ColumnType) {
+		stmts = append(stmts, column+" TYPE "+to.ColumnType)
+	}
+	if from.NotNull != to.NotNull {
+		if to.NotNull {
+			stmts = append(stmts, column+" SET NOT NULL")
+		} else {
+			stmts = append(stmts, column+" DROP NOT NULL")
+		}
+	}
+	if !sameDefault(to, from.ColumnDefault, to.ColumnDefault) {
+		if to.ColumnDefault == "" {
+			stmts = append(stmts, column+" DROP DEFAULT")
+		} else {
+			stmts = append(stmts, column+" SET DEFAULT "+to.ColumnDefault)
+		}
+	}
+	if from.ColumnComment != to.ColumnComment {
+		stmts = append(stmts, commentColumn(t, to))
+	}
+	return stmts
+}
+
+// columnDefinition returns the column definition used by CREATE TABLE and ADD COLUMN
+func columnDefinition(t *Table, c *Column) string {
+	var b strings.Builder
+	b.WriteString(quoteIdent(t, c.ColumnName) + " " + c.ColumnType)
+	if c.IsGenerated {
+		b.WriteString(" GENERATED ALWAYS AS (" + c.GenerationExpression + ")")
+		if c.IsStored {
+			b.WriteString(" STORED")
+		} else if t.Dialect != DialectPostgres {
+			b.WriteString(" VIRTUAL")
+		}
+	}
+	if c.NotNull {
+		b.WriteString(" NOT NULL")
+	} else if t.Dialect != DialectPostgres {
+		b.WriteString(" NULL")
+	}
+	if c.ColumnDefault != "" && !c.IsGenerated {
+		b.WriteString(" DEFAULT " + c.ColumnDefault)
+	}
+	if t.Dialect == DialectPostgres {
+		if c.IsAutoIncrment && !strings.Contains(c.ColumnType, "serial") {
+			b.WriteString(" GENERATED BY DEFAULT AS IDENTITY")
+		}
+	} else {
+		if c.IsOnUpdateCurrentTimestamp {
+			b.WriteString(" ON UPDATE CURRENT_TIMESTAMP" + timePrecision(c.ColumnType))
+		}
+		if c.IsAutoIncrment {
+			b.WriteString(" AUTO_INCREMENT")
+		}
+		if c.IsInvisible {
+			b.WriteString(" INVISIBLE")
+		}
+		if c.ColumnComment != "" {
+			b.WriteString(" COMMENT " + quoteLiteral(t, c.ColumnComment))
+		}
+	}
+	for _, v := range c.Checks {
+		b.WriteString(" " + checkDefinition(t, v))
+	}
+	return b.String()
+}
+
+func checkDefinition(t *Table, c *Check) string {
+	s := "CHECK (" + c.Expression + ")"
+	if c.Name != "" {
+		s = "CONSTRAINT " + quoteIdent(t, c.Name) + " " + s
+	}
+	if c.NotEnforced && t.Dialect != DialectPostgres {
+		s += " NOT ENFORCED"
+	}
+	return s
+}
+
+func foreignKeyDefinition(t *Table, fk *ForeignKey) string {
+	s := "FOREIGN KEY " + columnList(t, fk.Columns) + " REFERENCES " + quoteIdent(t, fk.RefTable)
+	if len(fk.RefColumns) > 0 {
+		var names []string
+		for _, v := range fk.RefColumns {
+			names = append(names, quoteIdent(t, v))
+		}
+		s += " (" + strings.Join(names, ", ") + ")"
+	}
+	if fk.OnDelete != "" {
+		s += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "" {
+		s += " ON UPDATE " + fk.OnUpdate
+	}
+	if fk.Name != "" {
+		s = "CONSTRAINT " + quoteIdent(t, fk.Name) + " " + s
+	}
+	return s
+}
+
+func commentTable(t *Table) string {
+	return fmt.Sprintf("COMMENT ON TABLE %s IS %s", quoteIdent(t, t.TableName), quoteLiteral(t, t.TableComment))
+}
+
+func commentColumn(t *Table, c *Column) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", quoteIdent(t, t.TableName), quoteIdent(t, c.ColumnName), quoteLiteral(t, c.ColumnComment))
+}
+
+func createIndex(t *Table, idx *Index) string {
+	kind := "INDEX"
+	switch {
+	case idx.Unique:
+		kind = "UNIQUE INDEX"
+	case idx.Fulltext && t.Dialect != DialectPostgres:
+		kind = "FULLTEXT INDEX"
+	}
+	using := ""
+	if idx.Fulltext && t.Dialect == DialectPostgres {
+		using = "USING gin "
+	}
+	return fmt.Sprintf("CREATE %s %s ON %s %s%s", kind, quoteIdent(t, idx.Name), quoteIdent(t, t.TableName), using, indexColumns(t, idx))
+}
+
+func addIndex(t *Table, idx *Index) string {
+	if idx.Primary {
+		return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY %s", quoteIdent(t, t.TableName), indexColumns(t, idx))
+	}
+	return createIndex(t, idx)
+}
+
+func dropIndex(t *Table, idx *Index) string {
+	switch {
+	case idx.Primary && t.Dialect == DialectPostgres:
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", quoteIdent(t, t.TableName), quoteIdent(t, idx.Name))
+	case idx.Primary:
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", quoteIdent(t, t.TableName))
+	case t.Dialect == DialectPostgres:
+		return "DROP INDEX " + quoteIdent(t, idx.Name)
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", quoteIdent(t, t.TableName), quoteIdent(t, idx.Name))
+}
+
+func indexColumns(t *Table, idx *Index) string {
+	return columnList(t, idx.Columns)
+}
+
+func columnList(t *Table, columns []*Column) string {
+	var names []string
+	for _, c := range columns {
+		names = append(names, quoteIdent(t, c.ColumnName))
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func findIndex(t *Table, idx *Index) *Index {
+	for _, v := range t.Indexes {
+		if (idx.Primary && v.Primary) || (!idx.Primary && !v.Primary && strings.EqualFold(v.Name, idx.Name)) {
+			return v
+		}
+	}
+	return nil
+}
+
+func findColumn(t *Table, name string) *Column {
+	for _, c := range t.Fields {
+		if strings.EqualFold(c.ColumnName, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+func sameIndex(a, b *Index) bool {
+	if a.Primary != b.Primary || a.Unique != b.Unique || a.Fulltext != b.Fulltext || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if !strings.EqualFold(a.Columns[i].ColumnName, b.Columns[i].ColumnName) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameColumn(a, b *Column) bool {
+	return sameColumnType(a.ColumnType, b.ColumnType) &&
+		a.NotNull == b.NotNull &&
+		sameDefault(b, a.ColumnDefault, b.ColumnDefault) &&
+		a.IsAutoIncrment == b.IsAutoIncrment &&
+		a.IsOnUpdateCurrentTimestamp == b.IsOnUpdateCurrentTimestamp &&
+		a.ColumnComment == b.ColumnComment &&
+		a.IsInvisible == b.IsInvisible &&
+		sameGeneration(a, b)
+}
+
+func sameGeneration(a, b *Column) bool {
+	return a.IsGenerated == b.IsGenerated && a.IsStored == b.IsStored &&
+		normalizeExpression(a.GenerationExpression) == normalizeExpression(b.GenerationExpression)
+}
+
+// intWidth the display width of integer type is deprecated, mysql 8 does not show it in information_schema
+var intWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+func sameColumnType(a, b string) bool {
+	return intWidth.ReplaceAllString(strings.ToLower(a), "$1") == intWidth.ReplaceAllString(strings.ToLower(b), "$1")
+}
+
+// sameDefault the defaults of the numeric column are compared by value, decimal DEFAULT 0 is the same as 0.00,
+// the others are compared as the literals without the postgres casts, varchar DEFAULT '1' is not the same as '01'
+func sameDefault(c *Column, a, b string) bool {
+	a, b = uncastDefault(a), uncastDefault(b)
+	if a == b {
+		return true
+	}
+	if !isNumericColumn(c) {
+		return false
+	}
+	x, err1 := strconv.ParseFloat(strings.Trim(a, "'"), 64)
+	y, err2 := strconv.ParseFloat(strings.Trim(b, "'"), 64)
+	return err1 == nil && err2 == nil && x == y
+}
+
+// uncastDefault removes the cast of the postgres literal, 'a'::character varying is 'a'
+func uncastDefault(def string) string {
+	if i := strings.Index(def, "::"); i > 0 && !strings.Contains(def[i:], "'") {
+		return def[:i]
+	}
+	return def
+}
+
+// isNumericColumn the mysql and postgres numeric data type
+func isNumericColumn(c *Column) bool {
+	switch c.DataType {
+	case "integer", "int2", "int4", "int8", "real", "float4", "float8", "double precision", "numeric":
+		return true
+	}
+	return isNumericType(c.DataType)
+}
+
+// normalizeExpression information_schema shows the expression rewritten by mysql,
+// quotes spaces and case are ignored
+func normalizeExpression(expr string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '`', '"':
+			return -1
+		}
+		return r
+	}, strings.ToLower(expr))
+}
+
+// timePrecision returns the (fsp) of datetime(3) timestamp(6)
+func timePrecision(columnType string) string {
+	if i := strings.Index(columnType, "("); i > 0 {
+		return columnType[i:]
+	}
+	return ""
+}
+
+func quoteIdent(t *Table, name string) string {
+	if t.Dialect == DialectPostgres {
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteLiteral backslash is a escape character of mysql string literal
+func quoteLiteral(t *Table, s string) string {
+	if t.Dialect != DialectPostgres {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return quoteString(s)
+}
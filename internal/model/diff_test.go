@@ -0,0 +1,217 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffTables(t *testing.T) {
+	current := "CREATE TABLE `user` (" +
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT," +
+		"`name` varchar(100) NOT NULL DEFAULT ''," +
+		"`age` int NOT NULL DEFAULT '0'," +
+		"`legacy` varchar(10) DEFAULT NULL," +
+		"`mtime` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP," +
+		"PRIMARY KEY (`id`)," +
+		"KEY `ix_name` (`name`)," +
+		"KEY `ix_age` (`age`));" +
+		"CREATE TABLE `acct` (`id` bigint NOT NULL, PRIMARY KEY (`id`));" +
+		"CREATE TABLE `gone` (`id` bigint NOT NULL);"
+	desired := "CREATE TABLE `user` (" +
+		"`id` int unsigned NOT NULL AUTO_INCREMENT," +
+		"`nick` varchar(32) NOT NULL DEFAULT '' COMMENT 'it''s nick'," +
+		"`name` varchar(200) NOT NULL DEFAULT ''," +
+		"`age` int NOT NULL DEFAULT 0," +
+		"`mtime` datetime NOT NULL DEFAULT now() ON UPDATE CURRENT_TIMESTAMP," +
+		"PRIMARY KEY (`id`)," +
+		"UNIQUE KEY `ix_name` (`name`)," +
+		"KEY `ix_age` (`age`));" +
+		"CREATE TABLE `account` (`id` bigint NOT NULL, `email` varchar(64) NOT NULL, PRIMARY KEY (`id`), KEY (`email`));" +
+		"CREATE TABLE `tag` (`id` bigint NOT NULL AUTO_INCREMENT, `t` datetime(3) DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP(3), PRIMARY KEY (`id`));"
+	from, err := ParseMysql("", current, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseMysql("", desired, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ALTER TABLE `acct` RENAME TO `account`",
+		"ALTER TABLE `user` DROP INDEX `ix_name`",
+		"ALTER TABLE `user` ADD COLUMN `nick` varchar(32) NOT NULL DEFAULT '' COMMENT 'it''s nick' AFTER `id`",
+		"ALTER TABLE `user` MODIFY COLUMN `name` varchar(200) NOT NULL DEFAULT ''",
+		"ALTER TABLE `user` DROP COLUMN `legacy`",
+		"CREATE UNIQUE INDEX `ix_name` ON `user` (`name`)",
+		"ALTER TABLE `account` ADD COLUMN `email` varchar(64) NOT NULL AFTER `id`",
+		"CREATE INDEX `email` ON `account` (`email`)",
+		"CREATE TABLE `tag` (\n" +
+			"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+			"  `t` datetime(3) NULL ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			")",
+		"DROP TABLE `gone`",
+	}
+	got := DiffTables(from, to, map[string]string{"acct": "account"}, true)
+	if strings.Join(got, ";\n") != strings.Join(want, ";\n") {
+		t.Fatalf("diff =\n%s\nwant\n%s", strings.Join(got, ";\n"), strings.Join(want, ";\n"))
+	}
+	if got := DiffTables(to, to, nil, true); len(got) != 0 {
+		t.Fatalf("diff of the same schema = %v", got)
+	}
+	// CREATE TABLE is parsed back to the same table
+	created, err := ParseMysql("", strings.Join(CreateTable(to[0]), ";"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DiffTables(created, to[:1], nil, false); len(got) != 0 {
+		t.Fatalf("diff of the created table = %v", got)
+	}
+}
+
+func TestDiffConstraints(t *testing.T) {
+	current := "CREATE TABLE `team` (`id` bigint NOT NULL, PRIMARY KEY (`id`));" +
+		"CREATE TABLE `member` (`id` bigint NOT NULL, `team_id` bigint NOT NULL, `owner_id` bigint NOT NULL," +
+		"`age` int NOT NULL CHECK (age >= 0), `score` int NOT NULL, PRIMARY KEY (`id`), KEY `fk_team` (`team_id`), KEY `fk_owner` (`owner_id`)," +
+		"CONSTRAINT `fk_team` FOREIGN KEY (`team_id`) REFERENCES `team` (`id`), CONSTRAINT `fk_owner` FOREIGN KEY (`owner_id`) REFERENCES `team` (`id`)," +
+		"CONSTRAINT `ck_score` CHECK (score < 100), CHECK (score > 0)) COMMENT='old';"
+	// mysql creates the index fk_team of the foreign key, the created tables are ordered by the references
+	desired := "CREATE TABLE `team` (`id` bigint NOT NULL, PRIMARY KEY (`id`));" +
+		"CREATE TABLE `member` (`id` bigint NOT NULL, `team_id` bigint NOT NULL, `org_id` bigint NOT NULL CHECK (org_id > 0)," +
+		"`age` int NOT NULL CHECK (`age` >= 0), `score` int NOT NULL, PRIMARY KEY (`id`)," +
+		"CONSTRAINT `fk_team` FOREIGN KEY (`team_id`) REFERENCES `team` (`id`) ON DELETE CASCADE, CONSTRAINT `fk_org` FOREIGN KEY (`org_id`) REFERENCES `org` (`id`)," +
+		"CONSTRAINT `ck_score` CHECK (score <= 100)) COMMENT='members';" +
+		"CREATE TABLE `project` (`id` bigint NOT NULL, `org_id` bigint NOT NULL, PRIMARY KEY (`id`), CONSTRAINT `fk_project_org` FOREIGN KEY (`org_id`) REFERENCES `org` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE);" +
+		"CREATE TABLE `org` (`id` bigint NOT NULL, `parent_id` bigint DEFAULT NULL, PRIMARY KEY (`id`), FOREIGN KEY (`parent_id`) REFERENCES `org` (`id`) ON DELETE SET NULL) COMMENT='it''s org';"
+	from, err := ParseMysql("", current, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseMysql("", desired, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ALTER TABLE `member` DROP FOREIGN KEY `fk_team`",
+		"ALTER TABLE `member` DROP FOREIGN KEY `fk_owner`",
+		"ALTER TABLE `member` DROP CHECK `ck_score`",
+		"-- ALTER TABLE `member` DROP the unnamed CHECK (score > 0) by the name generated by the database",
+		"ALTER TABLE `member` DROP INDEX `fk_owner`",
+		"ALTER TABLE `member` ADD COLUMN `org_id` bigint NOT NULL CHECK (org_id > 0) AFTER `team_id`",
+		"ALTER TABLE `member` DROP COLUMN `owner_id`",
+		"ALTER TABLE `member` ADD CONSTRAINT `ck_score` CHECK (score <= 100)",
+		"ALTER TABLE `member` COMMENT = 'members'",
+		"CREATE TABLE `org` (\n" +
+			"  `id` bigint NOT NULL,\n" +
+			"  `parent_id` bigint NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  FOREIGN KEY (`parent_id`) REFERENCES `org` (`id`) ON DELETE SET NULL\n" +
+			") COMMENT='it''s org'",
+		"CREATE TABLE `project` (\n" +
+			"  `id` bigint NOT NULL,\n" +
+			"  `org_id` bigint NOT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  CONSTRAINT `fk_project_org` FOREIGN KEY (`org_id`) REFERENCES `org` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE\n" +
+			")",
+		"ALTER TABLE `member` ADD CONSTRAINT `fk_team` FOREIGN KEY (`team_id`) REFERENCES `team` (`id`) ON DELETE CASCADE",
+		"ALTER TABLE `member` ADD CONSTRAINT `fk_org` FOREIGN KEY (`org_id`) REFERENCES `org` (`id`)",
+	}
+	got := DiffTables(from, to, nil, false)
+	if strings.Join(got, ";\n") != strings.Join(want, ";\n") {
+		t.Fatalf("diff =\n%s\nwant\n%s", strings.Join(got, ";\n"), strings.Join(want, ";\n"))
+	}
+	if got := DiffTables(to, to, nil, false); len(got) != 0 {
+		t.Fatalf("diff of the same schema = %v", got)
+	}
+	// the foreign keys, checks and comments of CREATE TABLE are parsed back
+	for _, v := range to {
+		created, err := ParseMysql("", strings.Join(CreateTable(v), ";"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := DiffTables(created, []*Table{v}, nil, false); len(got) != 0 {
+			t.Fatalf("diff of the created table %s = %v", v.TableName, got)
+		}
+	}
+	// RESTRICT is the same as NO ACTION and the missing action in mysql
+	restrict, err := ParseMysql("", strings.NewReplacer("ON DELETE CASCADE", "ON DELETE CASCADE ON UPDATE RESTRICT",
+		"ON DELETE RESTRICT", "ON DELETE NO ACTION").Replace(desired), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DiffTables(restrict, to, nil, false); len(got) != 0 {
+		t.Fatalf("diff of RESTRICT and NO ACTION = %v", got)
+	}
+}
+
+func TestDiffConstraintsPostgres(t *testing.T) {
+	current := "CREATE TABLE team (id bigint PRIMARY KEY);" +
+		"CREATE TABLE member (id bigint PRIMARY KEY, team_id bigint NOT NULL CONSTRAINT fk_team REFERENCES team, CONSTRAINT ck_id CHECK (id > 0));" +
+		"COMMENT ON TABLE member IS 'old';"
+	desired := "CREATE TABLE team (id bigint PRIMARY KEY);" +
+		"CREATE TABLE member (id bigint PRIMARY KEY, team_id bigint NOT NULL, CONSTRAINT ck_id CHECK (id > 1));" +
+		"ALTER TABLE member ADD CONSTRAINT fk_member_team FOREIGN KEY (team_id) REFERENCES team (id);" +
+		"CREATE TABLE tag (id bigint PRIMARY KEY, member_id bigint REFERENCES member ON DELETE SET NULL (member_id) ON UPDATE CASCADE);" +
+		"COMMENT ON TABLE tag IS 'tags';"
+	from, err := ParsePostgres("", current, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParsePostgres("", desired, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`ALTER TABLE "member" DROP CONSTRAINT "fk_team"`,
+		`ALTER TABLE "member" DROP CONSTRAINT "ck_id"`,
+		`ALTER TABLE "member" ADD CONSTRAINT "ck_id" CHECK (id > 1)`,
+		`COMMENT ON TABLE "member" IS ''`,
+		"CREATE TABLE \"tag\" (\n" +
+			"  \"id\" bigint NOT NULL,\n" +
+			"  \"member_id\" bigint,\n" +
+			"  PRIMARY KEY (\"id\"),\n" +
+			"  FOREIGN KEY (\"member_id\") REFERENCES \"member\" ON DELETE SET NULL (member_id) ON UPDATE CASCADE\n" +
+			")",
+		`COMMENT ON TABLE "tag" IS 'tags'`,
+		`ALTER TABLE "member" ADD CONSTRAINT "fk_member_team" FOREIGN KEY ("team_id") REFERENCES "team" ("id")`,
+	}
+	got := DiffTables(from, to, nil, false)
+	if strings.Join(got, ";\n") != strings.Join(want, ";\n") {
+		t.Fatalf("diff =\n%s\nwant\n%s", strings.Join(got, ";\n"), strings.Join(want, ";\n"))
+	}
+	created, err := ParsePostgres("", strings.Join(CreateTable(to[2]), ";"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DiffTables(created, to[2:], nil, false); len(got) != 0 {
+		t.Fatalf("diff of the created table = %v", got)
+	}
+}
+
+func TestDiffDefaults(t *testing.T) {
+	from, err := ParseMysql("", "CREATE TABLE `t` (`code` varchar(8) NOT NULL DEFAULT '1', `amount` decimal(10,2) NOT NULL DEFAULT '0', `n` int NOT NULL DEFAULT 1);", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseMysql("", "CREATE TABLE `t` (`code` varchar(8) NOT NULL DEFAULT '01', `amount` decimal(10,2) NOT NULL DEFAULT 0.00, `n` int NOT NULL DEFAULT '1.0');", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ALTER TABLE `t` MODIFY COLUMN `code` varchar(8) NOT NULL DEFAULT '01'"
+	if got := DiffTables(from, to, nil, false); strings.Join(got, ";\n") != want {
+		t.Fatalf("diff =\n%s\nwant\n%s", strings.Join(got, ";\n"), want)
+	}
+
+	from, err = ParsePostgres("", "CREATE TABLE t (code varchar(8) NOT NULL DEFAULT '1'::character varying, amount numeric(10,2) DEFAULT 0, name text DEFAULT 'a');", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err = ParsePostgres("", "CREATE TABLE t (code varchar(8) NOT NULL DEFAULT '01', amount numeric(10,2) DEFAULT 0.00, name text DEFAULT 'a'::text);", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `ALTER TABLE "t" ALTER COLUMN "code" SET DEFAULT '01'`
+	if got := DiffTables(from, to, nil, false); strings.Join(got, ";\n") != want {
+		t.Fatalf("diff =\n%s\nwant\n%s", strings.Join(got, ";\n"), want)
+	}
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// crudDirective generation directive in the table or column comment: @crud:skip @crud:name=UserID
+//
+//	@crud:skip        column: omit from the model, table: do not generate the table
+//	@crud:readonly    column: no setter in UpdateBuilder, table: all columns are readonly
+//	@crud:nowhere     column: no where funcs, table: no where funcs for all columns
+//	@crud:name=X      go field name of the column, go struct name of the table
+//	@crud:json=x      json tag of the column
+//	@crud:track       table: the model records the changes of its setters, Save updates the changed columns
+var crudDirective = regexp.MustCompile(`@crud:(\w+)(=\S*)?`)
+
+// directives returns the @crud: directives in the comment, the value of the flag directive is empty
+func directives(comment string) [][2]string {
+	var res [][2]string
+	for _, m := range crudDirective.FindAllStringSubmatch(comment, -1) {
+		res = append(res, [2]string{m[1], strings.TrimPrefix(m[2], "=")})
+	}
+	return res
+}
+
+// stripDirectives returns the comment without the @crud: directives and the @type: annotation,
+// they do not belong to the docs of the generated code
+func stripDirectives(comment string) string {
+	comment = jsonTypeAnnotation.ReplaceAllString(crudDirective.ReplaceAllString(comment, ""), "")
+	return strings.Join(strings.Fields(comment), " ")
+}
+
+// setTableDirectives apply the @crud: directives in the table comment
+func setTableDirectives(t *Table, comment string) error {
+	for _, d := range directives(comment) {
+		if err := tableDirective(t, d[0], d[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tableDirective(t *Table, key, value string) error {
+	switch key {
+	case "skip":
+		t.Skip = true
+	case "readonly":
+		for _, v := range t.Fields {
+			v.IsReadOnly = true
+		}
+	case "nowhere":
+		for _, v := range t.Fields {
+			v.NoWhere = true
+		}
+	case "track":
+		if len(t.PrimaryKeys) == 0 {
+			return fmt.Errorf("table %s: @crud:track needs a primary key", t.TableName)
+		}
+		t.Track = true
+	case "name":
+		if !token.IsIdentifier(value) || !token.IsExported(value) {
+			return fmt.Errorf("table %s: @crud:name=%s is not an exported go identifier", t.TableName, value)
+		}
+		t.GoTableName = value
+		t.ProtoName = lowerFirst(value)
+		t.PackageName = strings.ToLower(value)
+	default:
+		return fmt.Errorf("table %s: unknown directive @crud:%s", t.TableName, key)
+	}
+	return nil
+}
+
+// setColumnDirectives apply the @crud: directives in the column comment
+func setColumnDirectives(t *Table, c *Column) error {
+	for _, d := range directives(c.ColumnComment) {
+		if err := columnDirective(t, c, d[0], d[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnDirective(t *Table, c *Column, key, value string) error {
+	switch key {
+	case "skip":
+		if c.IsPrimaryKey {
+			return fmt.Errorf("table %s: primary key column %s can not be skipped", t.TableName, c.ColumnName)
+		}
+		c.IsSkip = true
+	case "readonly":
+		c.IsReadOnly = true
+	case "nowhere":
+		c.NoWhere = true
+	case "name":
+		if !token.IsIdentifier(value) || !token.IsExported(value) {
+			return fmt.Errorf("table %s: column %s @crud:name=%s is not an exported go identifier", t.TableName, c.ColumnName, value)
+		}
+		c.GoColumnName = value
+		c.ProtoName = lowerFirst(value)
+	case "json":
+		if value == "" {
+			return fmt.Errorf("table %s: column %s @crud:json needs a name", t.TableName, c.ColumnName)
+		}
+		c.JSONName = value
+	default:
+		return fmt.Errorf("table %s: column %s unknown directive @crud:%s", t.TableName, c.ColumnName, key)
+	}
+	return nil
+}
+
+// RemoveSkipped removes the @crud:skip columns and the finders of their indexes from the table before generating the code,
+// the parsed table keeps them to diff the schema
+func RemoveSkipped(t *Table) {
+	skipped := func(columns []*Column) bool {
+		for _, v := range columns {
+			if v.IsSkip {
+				return true
+			}
+		}
+		return false
+	}
+	if !skipped(t.Fields) {
+		return
+	}
+	var fields []*Column
+	for _, v := range t.Fields {
+		if !v.IsSkip {
+			fields = append(fields, v)
+		}
+	}
+	var finders []*Finder
+	for _, v := range t.Finders {
+		if !skipped(v.Columns) {
+			finders = append(finders, v)
+		}
+	}
+	var keysets []*Keyset
+	for _, v := range t.Keysets {
+		if !skipped(v.Columns) {
+			keysets = append(keysets, v)
+		}
+	}
+	t.Fields, t.Finders, t.Keysets = fields, finders, keysets
+	t.GenerateWhereCol = whereColumns(fields)
+}
+
+// lowerFirst the proto field name of the go name, UserID => userID
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// whereColumns the columns which where funcs are generated for
+func whereColumns(columns []*Column) []*Column {
+	var res []*Column
+	for _, v := range columns {
+		if !v.NoWhere && !v.IsSkip {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edge relationship to another table made by a single column foreign key. The foreign key makes a belongs to edge
+// on the referencing table and a has many edge on the referenced table, has one if the referencing column is a unique key
+type Edge struct {
+	Name      string  // go name of the edge, User for belongs to, Orders for has many
+	JSONName  string  // json tag of the edge, orders
+	Unique    bool    // the edge loads at most one record, belongs to and has one
+	BelongsTo bool    // Column references RefColumn of the referenced table
+	Column    *Column // column of the table
+	Ref       *Table  // table on the other side of the edge
+	RefColumn *Column // column of the other table which joins Column
+}
+
+// KeyType go type of the values which join the two tables, the type of the referenced column
+func (e *Edge) KeyType() string {
+	if e.BelongsTo {
+		return e.RefColumn.GoBaseType
+	}
+	return e.Column.GoBaseType
+}
+
+// KeyValue returns go expression of the not null value of the field expr converted to the key type of an edge
+func KeyValue(c *Column, typ, expr string) string {
+	if IsNullType(c) {
+		expr = NullValue(c, expr)
+	}
+	if c.GoBaseType != typ {
+		expr = typ + "(" + expr + ")"
+	}
+	return expr
+}
+
+// BuildEdges builds the edges of the tables by their foreign keys. The foreign keys reference a table which is not in the tables,
+// the composite foreign keys and the columns which can not be a map key are ignored
+func BuildEdges(tables []*Table) error {
+	byName := map[string]*Table{}
+	for _, t := range tables {
+		t.Edges = nil
+		byName[t.TableName] = t
+	}
+	type relation struct {
+		table, ref *Table
+		column     *Column
+		refColumn  *Column
+	}
+	var relations []*relation
+	count := map[[2]*Table]int{}
+	qualified := map[*Edge]bool{}
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			ref := byName[fk.RefTable]
+			if ref == nil || len(fk.Columns) != 1 {
+				continue
+			}
+			refColumn := ref.PrimaryKey
+			if len(fk.RefColumns) == 1 {
+				refColumn = ref.field(fk.RefColumns[0])
+			}
+			if refColumn == nil || !t.hasField(fk.Columns[0]) || !ref.hasField(refColumn) || !edgeKey(fk.Columns[0], refColumn) {
+				continue
+			}
+			relations = append(relations, &relation{table: t, ref: ref, column: fk.Columns[0], refColumn: refColumn})
+			count[[2]*Table{t, ref}]++
+		}
+	}
+	for _, r := range relations {
+		name := belongsToName(r.column)
+		unique := r.table.uniqueColumn(r.column)
+		has := r.table.GoTableName
+		if !unique {
+			has = Plural(has)
+		}
+		e := &Edge{Name: has, Unique: unique, Column: r.refColumn, Ref: r.table, RefColumn: r.column}
+		if count[[2]*Table{r.table, r.ref}] > 1 {
+			// user.OrdersByBuyer user.OrdersBySeller
+			e.Name += "By" + name
+			qualified[e] = true
+		}
+		r.table.Edges = append(r.table.Edges, &Edge{Name: name, Unique: true, BelongsTo: true, Column: r.column, Ref: r.ref, RefColumn: r.refColumn})
+		r.ref.Edges = append(r.ref.Edges, e)
+	}
+	for _, t := range tables {
+		names := map[string]int{}
+		for _, e := range t.Edges {
+			names[e.Name]++
+		}
+		seen := map[string]bool{}
+		for _, e := range t.Edges {
+			if names[e.Name] > 1 && !e.BelongsTo && !qualified[e] {
+				// player.Team belongs to team, player.TeamByCaptain is the team of which captain_id references player
+				e.Name += "By" + belongsToName(e.RefColumn)
+			}
+			if seen[e.Name] {
+				return fmt.Errorf("table %s: duplicate edge %s", t.TableName, e.Name)
+			}
+			seen[e.Name] = true
+			e.JSONName = SnakeCase(e.Name)
+		}
+	}
+	return nil
+}
+
+func (t *Table) field(name string) *Column {
+	for _, v := range t.Fields {
+		if v.ColumnName == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// hasField reports whether the column is generated in the model, the @crud:skip column is not
+func (t *Table) hasField(c *Column) bool {
+	for _, v := range t.Fields {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueColumn reports whether the column is a single column primary key or unique key
+func (t *Table) uniqueColumn(c *Column) bool {
+	for _, v := range t.Indexes {
+		if v.Unique && len(v.Columns) == 1 && v.Columns[0] == c {
+			return true
+		}
+	}
+	return false
+}
+
+// edgeKey reports whether the two columns can join by a go map key, both are integer or both are string
+func edgeKey(c, ref *Column) bool {
+	kind := func(c *Column) string {
+		switch {
+		case c.GoEnumType != "" || c.IsJSONType:
+			return ""
+		case c.GoBaseType == "string":
+			return "string"
+		case strings.HasPrefix(c.GoBaseType, "int"), strings.HasPrefix(c.GoBaseType, "uint"):
+			return "int"
+		}
+		return ""
+	}
+	return kind(c) != "" && kind(c) == kind(ref)
+}
+
+// belongsToName go name of the belongs to edge by the referencing column, UserId => User
+func belongsToName(c *Column) string {
+	for _, v := range []string{"ID", "Id"} {
+		if strings.HasSuffix(c.GoColumnName, v) && len(c.GoColumnName) > len(v) {
+			return strings.TrimSuffix(c.GoColumnName, v)
+		}
+	}
+	return c.GoColumnName
+}
+
+// Plural go name of the has many edge, UserRole => UserRoles Category => Categories Orders => Orders
+func Plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ss"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "s"):
+		return s
+	case len(s) > 1 && strings.HasSuffix(s, "y") && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
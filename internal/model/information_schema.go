@@ -0,0 +1,318 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ER_UNKNOWN_TABLE and ER_BAD_FIELD_ERROR of mysql
+const (
+	errUnknownTable  = 1109
+	errUnknownColumn = 1054
+)
+
+// SchemaQuerier query the information_schema of a live database, *sql.DB and *sql.Tx implement it
+type SchemaQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+const (
+	schemaTablesSQL = "SELECT `TABLE_NAME` FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = ? AND `TABLE_TYPE` IN ('BASE TABLE', 'VIEW') ORDER BY `TABLE_NAME`"
+
+	schemaTableCommentSQL = "SELECT `TABLE_COMMENT`, `TABLE_TYPE` FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ?"
+
+	schemaColumnsSQL = "SELECT `COLUMN_NAME`, `ORDINAL_POSITION`, `DATA_TYPE`, `COLUMN_TYPE`, `COLUMN_COMMENT`, `IS_NULLABLE`, `COLUMN_DEFAULT`, `EXTRA`, `GENERATION_EXPRESSION` " +
+		"FROM `information_schema`.`COLUMNS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ? ORDER BY `ORDINAL_POSITION`"
+
+	schemaIndexesSQL = "SELECT `INDEX_NAME`, `NON_UNIQUE`, `SEQ_IN_INDEX`, `COLUMN_NAME`, `INDEX_TYPE` " +
+		"FROM `information_schema`.`STATISTICS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ? ORDER BY `INDEX_NAME`, `SEQ_IN_INDEX`"
+
+	schemaForeignKeysSQL = "SELECT `kcu`.`CONSTRAINT_NAME`, `kcu`.`COLUMN_NAME`, `kcu`.`REFERENCED_TABLE_NAME`, `kcu`.`REFERENCED_COLUMN_NAME`, `rc`.`DELETE_RULE`, `rc`.`UPDATE_RULE` " +
+		"FROM `information_schema`.`KEY_COLUMN_USAGE` `kcu` JOIN `information_schema`.`REFERENTIAL_CONSTRAINTS` `rc` " +
+		"ON `rc`.`CONSTRAINT_SCHEMA` = `kcu`.`TABLE_SCHEMA` AND `rc`.`TABLE_NAME` = `kcu`.`TABLE_NAME` AND `rc`.`CONSTRAINT_NAME` = `kcu`.`CONSTRAINT_NAME` " +
+		"WHERE `kcu`.`TABLE_SCHEMA` = ? AND `kcu`.`TABLE_NAME` = ? AND `kcu`.`REFERENCED_TABLE_NAME` IS NOT NULL ORDER BY `kcu`.`CONSTRAINT_NAME`, `kcu`.`ORDINAL_POSITION`"
+
+	schemaChecksSQL = "SELECT `tc`.`CONSTRAINT_NAME`, `cc`.`CHECK_CLAUSE`, `tc`.`ENFORCED` FROM `information_schema`.`TABLE_CONSTRAINTS` `tc` " +
+		"JOIN `information_schema`.`CHECK_CONSTRAINTS` `cc` ON `cc`.`CONSTRAINT_SCHEMA` = `tc`.`CONSTRAINT_SCHEMA` AND `cc`.`CONSTRAINT_NAME` = `tc`.`CONSTRAINT_NAME` " +
+		"WHERE `tc`.`TABLE_SCHEMA` = ? AND `tc`.`TABLE_NAME` = ? AND `tc`.`CONSTRAINT_TYPE` = 'CHECK' ORDER BY `tc`.`CONSTRAINT_NAME`"
+)
+
+// SchemaTables read tables of the database from information_schema, all base tables and views are returned when tables is empty
+func SchemaTables(ctx context.Context, db SchemaQuerier, database string, tables []string, relative string) ([]*Table, error) {
+	if len(tables) == 0 {
+		var err error
+		tables, err = schemaTableNames(ctx, db, database)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var res []*Table
+	for _, v := range tables {
+		t, err := SchemaTable(ctx, db, database, v, relative)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// SchemaTable read a table or a view from information_schema and build the same Table as MysqlTable
+func SchemaTable(ctx context.Context, db SchemaQuerier, database, table, relative string) (*Table, error) {
+	columns, err := schemaColumns(ctx, db, database, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) <= 0 {
+		return nil, fmt.Errorf("table %s.%s not exist", database, table)
+	}
+	indexes, err := schemaIndexes(ctx, db, database, table, columns)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		if !idx.Primary {
+			continue
+		}
+		for _, v := range idx.Columns {
+			v.IsPrimaryKey = true
+			v.NotNull = true
+		}
+	}
+	for _, v := range columns {
+		v.ProtoType = GoTypeToProtoType(v.GoBaseType)
+	}
+	comment, view, err := schemaTableComment(ctx, db, database, table)
+	if err != nil {
+		return nil, err
+	}
+	t, err := NewTable(database, table, comment, relative, columns, indexes)
+	if err != nil {
+		return nil, err
+	}
+	t.IsView = view
+	if t.ForeignKeys, err = schemaForeignKeys(ctx, db, database, table, columns); err != nil {
+		return nil, err
+	}
+	if !view {
+		if t.Checks, err = schemaChecks(ctx, db, database, table); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// schemaChecks returns the checks of the table and its columns, mysql before 8.0.16 has no CHECK_CONSTRAINTS
+// or TABLE_CONSTRAINTS.ENFORCED and ignores the checks
+func schemaChecks(ctx context.Context, db SchemaQuerier, database, table string) ([]*Check, error) {
+	rows, err := db.QueryContext(ctx, schemaChecksSQL, database, table)
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && (me.Number == errUnknownTable || me.Number == errUnknownColumn) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Check
+	for rows.Next() {
+		var name, expr, enforced string
+		if err := rows.Scan(&name, &expr, &enforced); err != nil {
+			return nil, err
+		}
+		res = append(res, &Check{Name: name, Expression: expr, NotEnforced: enforced == "NO"})
+	}
+	return res, rows.Err()
+}
+
+func schemaForeignKeys(ctx context.Context, db SchemaQuerier, database, table string, columns []*Column) ([]*ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, schemaForeignKeysSQL, database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*ForeignKey
+	byName := map[string]*ForeignKey{}
+	for rows.Next() {
+		var name, column, refTable, refColumn, onDelete, onUpdate string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		fk, ok := byName[name]
+		if !ok {
+			// NO ACTION is the default which SHOW CREATE TABLE omits
+			fk = &ForeignKey{Name: name, RefTable: refTable, OnDelete: strings.TrimPrefix(onDelete, "NO ACTION"), OnUpdate: strings.TrimPrefix(onUpdate, "NO ACTION")}
+			byName[name] = fk
+			res = append(res, fk)
+		}
+		for _, c := range columns {
+			if c.ColumnName == column {
+				fk.Columns = append(fk.Columns, c)
+			}
+		}
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+	return res, rows.Err()
+}
+
+// schemaTableComment returns the comment of the table and whether it is a view, the comment of a view is VIEW
+func schemaTableComment(ctx context.Context, db SchemaQuerier, database, table string) (string, bool, error) {
+	rows, err := db.QueryContext(ctx, schemaTableCommentSQL, database, table)
+	if err != nil {
+		return "", false, err
+	}
+	defer rows.Close()
+	var comment, typ sql.NullString
+	if rows.Next() {
+		if err := rows.Scan(&comment, &typ); err != nil {
+			return "", false, err
+		}
+	}
+	if typ.String == "VIEW" {
+		return "", true, rows.Err()
+	}
+	return comment.String, false, rows.Err()
+}
+
+func schemaTableNames(ctx context.Context, db SchemaQuerier, database string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, schemaTablesSQL, database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		res = append(res, name)
+	}
+	return res, rows.Err()
+}
+
+func schemaColumns(ctx context.Context, db SchemaQuerier, database, table string) ([]*Column, error) {
+	rows, err := db.QueryContext(ctx, schemaColumnsSQL, database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Column
+	for rows.Next() {
+		var (
+			name, dataType, columnType, comment, nullable, extra, generation string
+			position                                                         int
+			def                                                              sql.NullString
+		)
+		if err := rows.Scan(&name, &position, &dataType, &columnType, &comment, &nullable, &def, &extra, &generation); err != nil {
+			return nil, err
+		}
+		// auto_increment, on update CURRENT_TIMESTAMP, DEFAULT_GENERATED, VIRTUAL GENERATED, STORED GENERATED, INVISIBLE
+		extra = strings.ToLower(extra)
+		c := &Column{
+			OrdinalPosition:      position - 1,
+			ColumnName:           name,
+			DataType:             strings.ToLower(dataType),
+			ColumnType:           schemaColumnType(dataType, columnType),
+			ColumnComment:        comment,
+			NotNull:              strings.EqualFold(nullable, "NO"),
+			IsAutoIncrment:       strings.Contains(extra, "auto_increment"),
+			IsGenerated:          strings.Contains(extra, "virtual generated") || strings.Contains(extra, "stored generated"),
+			GenerationExpression: generation,
+			IsStored:             strings.Contains(extra, "stored generated"),
+			IsInvisible:          strings.Contains(extra, "invisible"),
+		}
+		if def.Valid {
+			c.ColumnDefault = schemaDefault(c, def.String, extra)
+		}
+		c.IsDefaultCurrentTimestamp = strings.HasPrefix(c.ColumnDefault, "CURRENT_TIMESTAMP")
+		c.IsOnUpdateCurrentTimestamp = strings.Contains(extra, "on update current_timestamp")
+		setColumnGoType(c, MysqlToGoFieldType)
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// schemaColumnType lower case the column type except the values of enum and set
+func schemaColumnType(dataType, columnType string) string {
+	if i := strings.IndexByte(columnType, '('); i > 0 && (strings.EqualFold(dataType, "enum") || strings.EqualFold(dataType, "set")) {
+		return strings.ToLower(columnType[:i]) + columnType[i:]
+	}
+	return strings.ToLower(columnType)
+}
+
+// schemaDefault convert COLUMN_DEFAULT to sql literal, it is the expression for DEFAULT_GENERATED column
+// and the unquoted value for the others
+func schemaDefault(c *Column, def, extra string) string {
+	lower := strings.ToLower(def)
+	switch {
+	case strings.HasPrefix(lower, "current_timestamp"):
+		return mysqlDefaultValue(c, def)
+	case strings.Contains(extra, "default_generated"):
+		return "(" + def + ")"
+	case isNumericType(c.DataType), c.DataType == "bit" && strings.HasPrefix(lower, "b'"):
+		return def
+	}
+	return quoteString(def)
+}
+
+func schemaIndexes(ctx context.Context, db SchemaQuerier, database, table string, columns []*Column) ([]*Index, error) {
+	rows, err := db.QueryContext(ctx, schemaIndexesSQL, database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Index
+	byName := map[string]*Index{}
+	functional := map[string]bool{}
+	for rows.Next() {
+		var (
+			name, indexType string
+			nonUnique, seq  int
+			column          sql.NullString // null for functional key parts
+		)
+		if err := rows.Scan(&name, &nonUnique, &seq, &column, &indexType); err != nil {
+			return nil, err
+		}
+		idx, ok := byName[name]
+		if !ok {
+			idx = &Index{
+				Name:     name,
+				GoName:   GoCamelCase(name),
+				Primary:  name == "PRIMARY",
+				Unique:   nonUnique == 0,
+				Fulltext: strings.EqualFold(indexType, "FULLTEXT") || strings.EqualFold(indexType, "SPATIAL"),
+			}
+			if idx.Primary {
+				idx.GoName = "Primary"
+			}
+			byName[name] = idx
+			res = append(res, idx)
+		}
+		if !column.Valid {
+			functional[name] = true
+			continue
+		}
+		for _, c := range columns {
+			if c.ColumnName == column.String {
+				idx.Columns = append(idx.Columns, c)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	var indexes []*Index
+	for _, v := range res {
+		// finders can not use the index contains functional key parts
+		if !functional[v.Name] && len(v.Columns) > 0 {
+			indexes = append(indexes, v)
+		}
+	}
+	return sortIndexes(indexes), nil
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// information_schema emulated by an in-process sqlite database, the columns are the subset read by SchemaTable
+var informationSchema = []string{
+	"ATTACH DATABASE ':memory:' AS information_schema",
+	"CREATE TABLE information_schema.TABLES (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, TABLE_TYPE TEXT, TABLE_COMMENT TEXT)",
+	"CREATE TABLE information_schema.COLUMNS (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, COLUMN_NAME TEXT, ORDINAL_POSITION INTEGER, DATA_TYPE TEXT, COLUMN_TYPE TEXT, COLUMN_COMMENT TEXT, IS_NULLABLE TEXT, COLUMN_DEFAULT TEXT, EXTRA TEXT, GENERATION_EXPRESSION TEXT)",
+	"CREATE TABLE information_schema.STATISTICS (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, INDEX_NAME TEXT, NON_UNIQUE INTEGER, SEQ_IN_INDEX INTEGER, COLUMN_NAME TEXT, INDEX_TYPE TEXT)",
+	"CREATE TABLE information_schema.KEY_COLUMN_USAGE (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, CONSTRAINT_NAME TEXT, ORDINAL_POSITION INTEGER, COLUMN_NAME TEXT, REFERENCED_TABLE_NAME TEXT, REFERENCED_COLUMN_NAME TEXT)",
+	"CREATE TABLE information_schema.REFERENTIAL_CONSTRAINTS (CONSTRAINT_SCHEMA TEXT, CONSTRAINT_NAME TEXT, TABLE_NAME TEXT, REFERENCED_TABLE_NAME TEXT, UPDATE_RULE TEXT, DELETE_RULE TEXT)",
+	"CREATE TABLE information_schema.TABLE_CONSTRAINTS (CONSTRAINT_SCHEMA TEXT, CONSTRAINT_NAME TEXT, TABLE_SCHEMA TEXT, TABLE_NAME TEXT, CONSTRAINT_TYPE TEXT, ENFORCED TEXT)",
+	"CREATE TABLE information_schema.CHECK_CONSTRAINTS (CONSTRAINT_SCHEMA TEXT, CONSTRAINT_NAME TEXT, CHECK_CLAUSE TEXT)",
+	"INSERT INTO information_schema.TABLES VALUES ('test', 'account', 'BASE TABLE', '账户'), ('test', 'account_view', 'VIEW', 'VIEW')",
+	`INSERT INTO information_schema.COLUMNS VALUES
+	('test', 'account', 'id', 1, 'int', 'int(10) unsigned', 'id字段', 'NO', NULL, 'auto_increment', ''),
+	('test', 'account', 'email', 2, 'varchar', 'varchar(255)', '', 'NO', '', '', ''),
+	('test', 'account', 'name', 3, 'varchar', 'varchar(255)', '名称', 'NO', '', '', ''),
+	('test', 'account', 'bio', 4, 'text', 'text', '', 'YES', NULL, '', ''),
+	('test', 'account', 'mtime', 5, 'timestamp', 'timestamp', '', 'NO', 'CURRENT_TIMESTAMP', 'DEFAULT_GENERATED on update CURRENT_TIMESTAMP', ''),
+	('test', 'account', 'name_len', 6, 'int', 'int', '', 'YES', NULL, 'VIRTUAL GENERATED INVISIBLE', 'char_length(name)'),
+	('test', 'account_view', 'id', 1, 'int', 'int(10) unsigned', 'id字段', 'NO', '0', '', ''),
+	('test', 'account_view', 'name', 2, 'varchar', 'varchar(255)', '名称', 'NO', '', '', '')`,
+	`INSERT INTO information_schema.STATISTICS VALUES
+	('test', 'account', 'PRIMARY', 0, 1, 'id', 'BTREE'),
+	('test', 'account', 'ix_name_mtime', 1, 1, 'name', 'BTREE'),
+	('test', 'account', 'ix_name_mtime', 1, 2, 'mtime', 'BTREE'),
+	('test', 'account', 'uk_email', 0, 1, 'email', 'BTREE'),
+	('test', 'account', 'ix_lower_name', 1, 1, NULL, 'BTREE')`,
+	`INSERT INTO information_schema.KEY_COLUMN_USAGE VALUES
+	('test', 'account', 'PRIMARY', 1, 'id', NULL, NULL),
+	('test', 'account', 'fk_account_id', 1, 'id', 'user', 'id')`,
+	"INSERT INTO information_schema.REFERENTIAL_CONSTRAINTS VALUES ('test', 'fk_account_id', 'account', 'user', 'NO ACTION', 'CASCADE')",
+	// the unnamed check has the generated name, the expressions are rewritten by mysql
+	`INSERT INTO information_schema.TABLE_CONSTRAINTS VALUES
+	('test', 'PRIMARY', 'test', 'account', 'PRIMARY KEY', 'YES'),
+	('test', 'account_chk_1', 'test', 'account', 'CHECK', 'YES'),
+	('test', 'ck_name', 'test', 'account', 'CHECK', 'NO')`,
+	"INSERT INTO information_schema.CHECK_CONSTRAINTS VALUES ('test', 'account_chk_1', '(`id` > 0)'), ('test', 'ck_name', '(char_length(`name`) > 0)')",
+}
+
+const accountDDL = "CREATE TABLE `account` (" +
+	"`id` int(10) unsigned NOT NULL AUTO_INCREMENT COMMENT 'id字段' CHECK (id > 0)," +
+	"`email` varchar(255) NOT NULL DEFAULT ''," +
+	"`name` varchar(255) NOT NULL DEFAULT '' COMMENT '名称'," +
+	"`bio` text," +
+	"`mtime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP," +
+	"`name_len` int GENERATED ALWAYS AS (char_length(name)) VIRTUAL INVISIBLE," +
+	"PRIMARY KEY (`id`)," +
+	"UNIQUE KEY `uk_email` (`email`)," +
+	"KEY `ix_name_mtime` (`name`,`mtime`)," +
+	"CONSTRAINT `fk_account_id` FOREIGN KEY (`id`) REFERENCES `user` (`id`) ON DELETE CASCADE," +
+	"CONSTRAINT `ck_name` CHECK (char_length(name) > 0) NOT ENFORCED" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='账户'"
+
+func TestSchemaTables(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// attached database only lives in one connection
+	db.SetMaxOpenConns(1)
+	for _, v := range informationSchema {
+		if _, err := db.Exec(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tables, err := SchemaTables(context.Background(), db, "test", nil, "example/crud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].IsView || !tables[1].IsView || tables[1].TableComment != "" || len(tables[1].Fields) != 2 {
+		t.Fatalf("tables = %d, want account and account_view", len(tables))
+	}
+
+	path := filepath.Join(t.TempDir(), "account.sql")
+	if err := os.WriteFile(path, []byte(accountDDL), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, err := MysqlTable("test", path, "example/crud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := tableSummary(tables[0]), tableSummary(want); got != exp {
+		t.Fatalf("information_schema table\n%s\nwant the same as ddl table\n%s", got, exp)
+	}
+
+	if stmts := DiffTables(tables, []*Table{want}, nil, false); len(stmts) > 0 {
+		t.Fatalf("information_schema table differ from ddl table %v", stmts)
+	}
+	if len(tables[0].Checks) != 2 || tables[0].Checks[0].NotEnforced || !tables[0].Checks[1].NotEnforced {
+		t.Fatalf("checks = %+v", tables[0].Checks)
+	}
+
+	if _, err := SchemaTable(context.Background(), db, "test", "not_exist", ""); err == nil {
+		t.Fatal("want error for not exist table")
+	}
+}
+
+func tableSummary(t *Table) string {
+	s := fmt.Sprintf("%s %s %s pk=%s %q\n", t.Database, t.GoTableName, t.PackageName, PKTool(t, "args"), t.TableComment)
+	for _, c := range t.Fields {
+		s += fmt.Sprintf("%d %s %s %s %s %s notnull=%v pk=%v ai=%v dct=%v default=%s onupdate=%v gen=%v:%v:%q invisible=%v %q\n", c.OrdinalPosition, c.ColumnName, c.ColumnType,
+			c.GoColumnType, c.GoConditionType, c.ProtoType, c.NotNull, c.IsPrimaryKey, c.IsAutoIncrment, c.IsDefaultCurrentTimestamp, c.ColumnDefault, c.IsOnUpdateCurrentTimestamp,
+			c.IsGenerated, c.IsStored, c.GenerationExpression, c.IsInvisible, c.ColumnComment)
+	}
+	for _, v := range t.Indexes {
+		s += fmt.Sprintf("index %s %s unique=%v\n", v.Name, ColumnTool(v.Columns, "field"), v.Unique)
+	}
+	for _, v := range t.Finders {
+		s += fmt.Sprintf("finder %s %v %s\n", v.GoName, v.Unique, v.Index.Name)
+	}
+	for _, v := range t.ForeignKeys {
+		s += fmt.Sprintf("foreign key %s %s references %s %v on delete %q on update %q\n", v.Name, ColumnTool(v.Columns, "field"), v.RefTable, v.RefColumns, v.OnDelete, v.OnUpdate)
+	}
+	return s
+}
@@ -0,0 +1,332 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind kind of the sql token
+type tokenKind int
+
+const (
+	tokenEOF    tokenKind = iota
+	tokenIdent            // keyword or bare identifier
+	tokenQuoted           // "ident" or `ident`
+	tokenString           // 'string'
+	tokenNumber           // 123 1.5
+	tokenPunct            // ( ) , ; . :: and operators
+)
+
+// sqlToken a lexical token of sql statement
+type sqlToken struct {
+	kind tokenKind
+	text string // unquoted text for quoted identifier and string
+	raw  string // text as it is in the statement
+}
+
+// lexSQL split the sql into tokens, comments are dropped.
+// backslash is a escape character in string literals when backslash is true (mysql),
+// the content of mysql version comment /*!80023 INVISIBLE */ is lexed as sql like mysql executes it
+func lexSQL(sql string, backslash bool) ([]sqlToken, error) {
+	var toks []sqlToken
+	var version bool // in mysql version comment
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '/' && backslash && strings.HasPrefix(sql[i:], "/*!"):
+			i += 3
+			for i < len(sql) && sql[i] >= '0' && sql[i] <= '9' {
+				i++
+			}
+			version = true
+		case c == '*' && version && strings.HasPrefix(sql[i:], "*/"):
+			i += 2
+			version = false
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			i = skipLine(sql, i)
+		case c == '#' && backslash:
+			i = skipLine(sql, i)
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			text, n, err := lexQuoted(sql[i:], c, backslash && c != '`')
+			if err != nil {
+				return nil, fmt.Errorf("%v at offset %d", err, i)
+			}
+			kind := tokenQuoted
+			if c == '\'' {
+				kind = tokenString
+			}
+			toks = append(toks, sqlToken{kind: kind, text: text, raw: sql[i : i+n]})
+			i += n
+		case isIdentByte(c):
+			j := i
+			for j < len(sql) && (isIdentByte(sql[j]) || (sql[j] >= '0' && sql[j] <= '9') || sql[j] == '$') {
+				j++
+			}
+			toks = append(toks, sqlToken{kind: tokenIdent, text: sql[i:j], raw: sql[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.' || isIdentByte(sql[j])) {
+				j++
+			}
+			toks = append(toks, sqlToken{kind: tokenNumber, text: sql[i:j], raw: sql[i:j]})
+			i = j
+		default:
+			n := 1
+			for _, op := range operators {
+				if strings.HasPrefix(sql[i:], op) {
+					n = len(op)
+					break
+				}
+			}
+			toks = append(toks, sqlToken{kind: tokenPunct, text: sql[i : i+n], raw: sql[i : i+n]})
+			i += n
+		}
+	}
+	return toks, nil
+}
+
+// operators the multi-character operators, the longer one is first
+var operators = []string{
+	"<=>", "->>", "#>>", "!~*",
+	"::", "<>", "<=", ">=", "!=", "||", "&&", "<<", ">>", "->", ":=", "@>", "<@", "#>", "~*", "!~",
+}
+
+func skipLine(sql string, i int) int {
+	end := strings.IndexByte(sql[i:], '\n')
+	if end < 0 {
+		return len(sql)
+	}
+	return i + end + 1
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// lexQuoted returns the unquoted text and the length of the quoted token, the quote is escaped by doubling it
+func lexQuoted(s string, quote byte, backslash bool) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && backslash && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(s[i])
+			}
+		case c == quote && i+1 < len(s) && s[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		case c == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c", quote)
+}
+
+// splitStatements split tokens into statements by semicolon
+func splitStatements(toks []sqlToken) [][]sqlToken {
+	var stmts [][]sqlToken
+	start := 0
+	for i, t := range toks {
+		if t.kind == tokenPunct && t.text == ";" {
+			if i > start {
+				stmts = append(stmts, toks[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		stmts = append(stmts, toks[start:])
+	}
+	return stmts
+}
+
+// tokenParser a cursor over the tokens of one statement
+type tokenParser struct {
+	toks []sqlToken
+	pos  int
+}
+
+func (p *tokenParser) peek() sqlToken {
+	return p.peekN(0)
+}
+
+func (p *tokenParser) peekN(n int) sqlToken {
+	if p.pos+n >= len(p.toks) {
+		return sqlToken{kind: tokenEOF}
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *tokenParser) next() sqlToken {
+	t := p.peek()
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *tokenParser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+// is reports whether the next tokens are the keywords or punctuations, case insensitive
+func (p *tokenParser) is(words ...string) bool {
+	for i, w := range words {
+		t := p.peekN(i)
+		if (t.kind != tokenIdent && t.kind != tokenPunct) || !strings.EqualFold(t.text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consume the words if the next tokens are the words
+func (p *tokenParser) accept(words ...string) bool {
+	if !p.is(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *tokenParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return p.errorf("expect %s", strings.Join(words, " "))
+	}
+	return nil
+}
+
+// ident consume an identifier, bare identifier is returned as it is
+func (p *tokenParser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent && t.kind != tokenQuoted {
+		return "", p.errorf("expect identifier")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// identList consume a parenthesized identifier list (a, b), the identifiers are parsed by ident
+func (p *tokenParser) identList(ident func(*tokenParser) (string, error)) ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := ident(p)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return names, p.expect(")")
+}
+
+// skipParens consume a balanced parenthesized group and return the raw text inside it
+func (p *tokenParser) skipParens() (string, error) {
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+	start := p.pos
+	for depth := 1; ; {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return "", p.errorf("unbalanced parentheses")
+		case t.kind == tokenPunct && t.text == "(":
+			depth++
+		case t.kind == tokenPunct && t.text == ")":
+			depth--
+			if depth == 0 {
+				return joinTokens(p.toks[start : p.pos-1]), nil
+			}
+		}
+	}
+}
+
+// skipUntil consume tokens until one of the punctuations at depth 0, the punctuation is not consumed
+func (p *tokenParser) skipUntil(puncts ...string) string {
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		t := p.peek()
+		if t.kind == tokenPunct {
+			switch {
+			case t.text == "(":
+				depth++
+			case t.text == ")" && depth > 0:
+				depth--
+			case depth == 0 && contains(puncts, t.text):
+				return joinTokens(p.toks[start:p.pos])
+			}
+		}
+		p.pos++
+	}
+	return joinTokens(p.toks[start:p.pos])
+}
+
+func (p *tokenParser) errorf(format string, args ...interface{}) error {
+	near := "end of statement"
+	if t := p.peek(); t.kind != tokenEOF {
+		near = fmt.Sprintf("%q", t.raw)
+	}
+	return fmt.Errorf(format+" near "+near, args...)
+}
+
+// joinTokens rebuild the sql text of the tokens
+func joinTokens(toks []sqlToken) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 && needSpace(toks[i-1], t) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.raw)
+	}
+	return b.String()
+}
+
+func needSpace(prev, t sqlToken) bool {
+	if prev.kind == tokenPunct && (prev.text == "(" || prev.text == "." || prev.text == "::") {
+		return false
+	}
+	if t.kind == tokenPunct && (t.text == ")" || t.text == "," || t.text == "." || t.text == "::") {
+		return false
+	}
+	if t.kind == tokenPunct && t.text == "(" && prev.kind == tokenIdent {
+		return false
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TableOverride settings of a table in the project config, they are applied like the @crud: directives of the table comment
+type TableOverride struct {
+	Name     string                     `yaml:"name"`     // go struct name, @crud:name=
+	Package  string                     `yaml:"package"`  // package name, the lower case go struct name by default
+	Skip     bool                       `yaml:"skip"`     // @crud:skip
+	ReadOnly bool                       `yaml:"readonly"` // @crud:readonly
+	NoWhere  bool                       `yaml:"nowhere"`  // @crud:nowhere
+	Track    bool                       `yaml:"track"`    // @crud:track
+	Columns  map[string]*ColumnOverride `yaml:"columns"`  // settings of the columns by the column name
+}
+
+// ColumnOverride settings of a column in the project config, they are applied like the @crud: directives of the column comment
+type ColumnOverride struct {
+	Name     string `yaml:"name"`     // go field name, @crud:name=
+	JSON     string `yaml:"json"`     // json tag, @crud:json=
+	Type     string `yaml:"type"`     // go type, the type other than the builtin types is marshaled by encoding/json like @type:
+	Skip     bool   `yaml:"skip"`     // @crud:skip
+	ReadOnly bool   `yaml:"readonly"` // @crud:readonly
+	NoWhere  bool   `yaml:"nowhere"`  // @crud:nowhere
+}
+
+// ApplyOverride apply the type mappings and the table settings of the project config to the parsed table,
+// types maps the column type or the data type to the go type: tinyint(1): bool, json: map[string]interface{}
+func ApplyOverride(t *Table, types map[string]string, o *TableOverride) error {
+	for _, c := range t.Fields {
+		typ, ok := types[c.ColumnType]
+		if !ok {
+			typ, ok = types[c.DataType]
+		}
+		if ok {
+			if err := SetColumnType(t, c, typ); err != nil {
+				return err
+			}
+		}
+	}
+	if o == nil {
+		return t.derive()
+	}
+	apply := func(set bool, key, value string) error {
+		if !set {
+			return nil
+		}
+		return tableDirective(t, key, value)
+	}
+	if err := apply(o.Skip, "skip", ""); err != nil {
+		return err
+	}
+	if err := apply(o.ReadOnly, "readonly", ""); err != nil {
+		return err
+	}
+	if err := apply(o.NoWhere, "nowhere", ""); err != nil {
+		return err
+	}
+	if err := apply(o.Track, "track", ""); err != nil {
+		return err
+	}
+	if err := apply(o.Name != "", "name", o.Name); err != nil {
+		return err
+	}
+	if o.Package != "" {
+		t.PackageName = o.Package
+	}
+	// sorted to report the same error for every run
+	var names []string
+	for name := range o.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c, co := t.field(name), o.Columns[name]
+		if c == nil {
+			return fmt.Errorf("table %s: column %s of the config is not exist", t.TableName, name)
+		}
+		apply := func(set bool, key, value string) error {
+			if !set {
+				return nil
+			}
+			return columnDirective(t, c, key, value)
+		}
+		for _, err := range []error{
+			apply(co.Skip, "skip", ""),
+			apply(co.ReadOnly, "readonly", ""),
+			apply(co.NoWhere, "nowhere", ""),
+			apply(co.Name != "", "name", co.Name),
+			apply(co.JSON != "", "json", co.JSON),
+		} {
+			if err != nil {
+				return err
+			}
+		}
+		if co.Type != "" {
+			if err := SetColumnType(t, c, co.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return t.derive()
+}
+
+// SetColumnType change the go type of the column. The builtin types are string bool []byte time.Time xsql.Decimal
+// and the integer and float types, the other types such as map[string]interface{} pkg.Settings are marshaled
+// by encoding/json and the column must be a string column
+func SetColumnType(t *Table, c *Column, typ string) error {
+	c.typeSet = true
+	if GoTypeToProtoType(typ) == "" {
+		if c.GoBaseType != "string" && !c.IsJSONType && c.GoEnumType == "" {
+			return fmt.Errorf("table %s: column %s of %s can not be %s, the json type needs a string column", t.TableName, c.ColumnName, c.GoBaseType, typ)
+		}
+		c.GoEnumType, c.EnumValues = "", nil
+		setJSONGoType(t, c, typ)
+		c.ProtoType = "string"
+		return nil
+	}
+	c.GoEnumType, c.EnumValues = "", nil
+	c.IsJSONType, c.GoImport = false, ""
+	c.GoColumnType = typ
+	c.GoBaseType = typ
+	c.GoConditionType = typ
+	c.ProtoType = GoTypeToProtoType(typ)
+	c.Precision, c.Scale = 0, 0
+	switch {
+	case typ == "time.Time":
+		c.BigType = bigtypeCompareTime
+		c.GoConditionType = "string"
+	case typ == "string":
+		c.BigType = bigtypeCompareString
+	case typ == "bool" || typ == "[]byte":
+		c.BigType = 0
+	default:
+		c.BigType = bigtypeCompare
+	}
+	if typ == DecimalType {
+		c.Precision, c.Scale = decimalSize(c.ColumnType)
+		if c.Precision == 0 && t.Dialect == DialectMySQL {
+			c.Precision = 10
+		}
+	}
+	return nil
+}
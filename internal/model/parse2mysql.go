@@ -1,25 +1,71 @@
 package model
 
 import (
+	"fmt"
 	"io/ioutil"
-	"log"
+	"regexp"
+	"strconv"
 	"strings"
-
-	"github.com/xwb1989/sqlparser"
 )
 
 // Table Table
 type Table struct {
+	Dialect          string // DialectMySQL or DialectPostgres
 	Database         string
-	TableName        string    // table name
-	GoTableName      string    // go struct name
-	PackageName      string    // package name
-	Fields           []*Column // columns
-	GenerateWhereCol []*Column // GenerateWhereCol 生成where字段比较方法的列
-	PrimaryKey       *Column   // priomary_key column
-	ImportTime       bool      // is need import time
+	TableName        string        // table name
+	TableComment     string        // table comment
+	ProtoName        string        // proto field name of the table message, GoTableName is its camel case
+	GoTableName      string        // go struct name
+	PackageName      string        // package name
+	Fields           []*Column     // columns
+	GenerateWhereCol []*Column     // GenerateWhereCol 生成where字段比较方法的列
+	PrimaryKey       *Column       // priomary_key column, nil when the primary key is composite
+	PrimaryKeys      []*Column     // primary key columns in index order
+	Indexes          []*Index      // all indexes include primary key
+	Checks           []*Check      // table check constraints
+	ForeignKeys      []*ForeignKey // foreign key constraints
+	Edges            []*Edge       // relationships to the other tables built by BuildEdges
+	Finders          []*Finder     // finder methods generated from indexes
+	Keysets          []*Keyset     // keyset orders of the cursor pagination, empty without primary key
+	ImportTime       bool          // is need import time
+	ImportSQL        bool          // is need import database/sql
 	RelativePath     string
+	OutputDir        string // folder of the generated packages relative to RelativePath, crud by default
 	Protopkg         string
+	ProtoWrapper     bool // nullable column use google.protobuf wrapper type in proto message
+	Skip             bool // the table is annotated by @crud:skip, no code is generated
+	IsView           bool // the table is a view, only the read methods are generated
+	Track            bool // @crud:track, the model records the changed columns which Save updates
+}
+
+// JSONImports returns the import paths of the go types annotated by @type:
+func (t *Table) JSONImports() []string {
+	var res []string
+	seen := map[string]bool{}
+	for _, v := range t.Fields {
+		if v.GoImport != "" && !seen[v.GoImport] {
+			seen[v.GoImport] = true
+			res = append(res, v.GoImport)
+		}
+	}
+	return res
+}
+
+// OrderKeysets returns the keyset order of each leading column, the one with the fewest columns is preferred,
+// List reads by the cursor when it is ordered by the leading column
+func (t *Table) OrderKeysets() []*Keyset {
+	var res []*Keyset
+	seen := map[*Column]int{}
+	for _, v := range t.Keysets {
+		i, ok := seen[v.Columns[0]]
+		if !ok {
+			seen[v.Columns[0]] = len(res)
+			res = append(res, v)
+		} else if len(v.Columns) < len(res[i].Columns) {
+			res[i] = v
+		}
+	}
+	return res
 }
 
 // Column Column
@@ -29,128 +75,1155 @@ type Column struct {
 	DataType                  string // data_type
 	ColumnType                string // column_type
 	ColumnComment             string // column_comment,
+	Comment                   string // column_comment without the @crud: and @type: directives, the comment of the generated code
 	NotNull                   bool   // not_null
 	IsPrimaryKey              bool   // is_primary_key
 	IsAutoIncrment            bool   // is_auto_incrment
 	IsDefaultCurrentTimestamp bool   // is_default_currenttimestamp
 	GoColumnName              string // go field name
 	GoColumnType              string // go field type
+	GoBaseType                string // go field type when the column is not null
 	BigType                   int    // 0 表示不生成where 1 表示比较类型 2表示比较类型+字符串 3表示比较类型，修改传入参数
 	GoConditionType           string // 生成where 的类型参数
 	ProtoType                 string // protoType
+
+	ColumnDefault              string   // column_default as sql literal: 'a' 0 CURRENT_TIMESTAMP (expr), empty if no default or DEFAULT NULL
+	IsOnUpdateCurrentTimestamp bool     // ON UPDATE CURRENT_TIMESTAMP
+	IsGenerated                bool     // generated column, the value is computed by GenerationExpression
+	GenerationExpression       string   // generation_expression
+	IsStored                   bool     // stored generated column, false for virtual
+	IsInvisible                bool     // invisible column is not returned by SELECT *
+	Checks                     []*Check // check constraints declared with the column
+
+	EnumValues []*EnumValue // values of enum and set column in definition order
+	GoEnumType string       // go type of enum and set column, empty for the others
+	IsJSONType bool         // the go type is annotated by @type: in comment, the value is marshaled by encoding/json
+	GoImport   string       // import path of the annotated go type, empty for the builtin types
+	Precision  int          // precision of decimal column, 0 if not declared
+	Scale      int          // scale of decimal column
+	JSONName   string       // json tag of the field, the column name unless annotated by @crud:json=
+	ProtoName  string       // proto field name, GoColumnName is its camel case
+	IsSkip     bool         // @crud:skip the column is omitted from the generated model
+	IsReadOnly bool         // @crud:readonly or generated column, no setter in UpdateBuilder
+	NoWhere    bool         // @crud:nowhere no where funcs
+	typeSet    bool         // the go type is set by SetColumnType, SetExactInt keeps it
+}
+
+// EnumValue value of enum or set column
+type EnumValue struct {
+	Value  string // value in the DDL
+	GoName string // go const name, the enum type name + camel case value
+}
+
+// Check check constraint
+type Check struct {
+	Name        string // constraint name, empty if not named
+	Expression  string // check expression
+	NotEnforced bool   // NOT ENFORCED constraint is not checked by mysql
+}
+
+// ForeignKey foreign key constraint
+type ForeignKey struct {
+	Name       string    // constraint name, empty if not named
+	Columns    []*Column // referencing columns of the table
+	RefTable   string    // referenced table
+	RefColumns []string  // referenced columns, empty for the primary key of the referenced table
+	OnDelete   string    // referential action of ON DELETE: CASCADE, SET NULL, SET DEFAULT, RESTRICT or NO ACTION, empty if not given
+	OnUpdate   string    // referential action of ON UPDATE, empty if not given
+}
+
+// Index Index
+type Index struct {
+	Name     string    // index name
+	GoName   string    // go const name suffix
+	Primary  bool      // is primary key
+	Unique   bool      // is unique key
+	Fulltext bool      // is fulltext or spatial key, not used by finders
+	Columns  []*Column // index columns in index order
+}
+
+// Finder lookup by the leftmost prefix columns of an index
+type Finder struct {
+	GoName  string    // concatenated go field name of columns, NameMtime
+	Unique  bool      // columns is a unique key, lookup return one record
+	Index   *Index    // the index used by lookup
+	Columns []*Column // leftmost prefix columns of the index
+}
+
+// Keyset order of the cursor pagination, the columns of an index followed by the primary key as the tie-breaker
+type Keyset struct {
+	Name    string    // index name, PRIMARY for the primary key
+	GoName  string    // concatenated go field name of the index columns, PK for the primary key
+	Columns []*Column // index columns and the primary key columns not in the index
 }
 
-func MysqlColumn(ddl *sqlparser.DDL) ([]*Column, error) {
+// setColumnGoType set the go name and go types of the column by the type mapping of the dialect
+func setColumnGoType(c *Column, goFieldType func(dt, ct string) (string, int)) {
+	c.GoColumnName = GoCamelCase(c.ColumnName)
+	c.GoColumnType, c.BigType = goFieldType(c.DataType, c.ColumnType)
+	if strings.Contains(c.GoColumnType, "int") {
+		c.GoColumnType = "int64"
+	}
+	c.GoBaseType = c.GoColumnType
+	c.GoConditionType = c.GoColumnType
+	if c.BigType == bigtypeCompareTime {
+		c.GoConditionType = "string"
+	}
+	if c.GoBaseType == DecimalType {
+		c.Precision, c.Scale = decimalSize(c.ColumnType)
+	}
+	if (c.DataType == "enum" || c.DataType == "set") && strings.HasSuffix(c.ColumnType, ")") {
+		c.EnumValues = nil
+		for _, v := range enumValues(c.ColumnType) {
+			c.EnumValues = append(c.EnumValues, &EnumValue{Value: v})
+		}
+	}
+}
 
-	res := []*Column{}
-	for k, v := range ddl.TableSpec.Columns {
+// decimalSize returns the precision and scale of the column type decimal(10,2) numeric(8)
+func decimalSize(ct string) (int, int) {
+	start, end := strings.IndexByte(ct, '('), strings.IndexByte(ct, ')')
+	if start < 0 || end < start {
+		return 0, 0
+	}
+	args := strings.SplitN(ct[start+1:end], ",", 2)
+	precision, _ := strconv.Atoi(strings.TrimSpace(args[0]))
+	var scale int
+	if len(args) == 2 {
+		scale, _ = strconv.Atoi(strings.TrimSpace(args[1]))
+	}
+	return precision, scale
+}
 
-		var ct string
-		if v.Type.Unsigned {
-			ct = "unsigned"
+// enumValues returns the values of the column type enum('a','b') or set('a','b'),
+// the quote in value is doubled or escaped by backslash
+func enumValues(ct string) []string {
+	var res []string
+	for i := strings.IndexByte(ct, '(') + 1; i > 0 && i < len(ct); i++ {
+		if ct[i] != '\'' {
+			continue
 		}
-		var dct bool
-		if v.Type.Default != nil {
-			if string(v.Type.Default.Val) == "current_timestamp" || string(v.Type.Default.Val) == "current_timestamp()" {
-				dct = true
+		var b strings.Builder
+		for i++; i < len(ct); i++ {
+			if ct[i] == '\\' && i+1 < len(ct) {
+				i++
+			} else if ct[i] == '\'' {
+				if i+1 >= len(ct) || ct[i+1] != '\'' {
+					break
+				}
+				i++
 			}
+			b.WriteByte(ct[i])
 		}
-		var comment string
-		if v.Type.Comment != nil {
-			comment = string(v.Type.Comment.Val)
+		res = append(res, b.String())
+	}
+	return res
+}
+
+var jsonTypeAnnotation = regexp.MustCompile(`@type:(\S+)`)
+
+// setJSONType change the go type of the string column to the type annotated in comment like
+// @type:pkg.Settings @type:[]*github.com/acme/types.Tag @type:map[string]interface{},
+// the import path is relative to the package of the working directory when the first element of it has no dot
+func setJSONType(t *Table, c *Column) {
+	m := jsonTypeAnnotation.FindStringSubmatch(c.ColumnComment)
+	if m == nil || c.GoBaseType != "string" {
+		return
+	}
+	setJSONGoType(t, c, m[1])
+}
+
+// setJSONGoType change the go type of the column to the type marshaled by encoding/json
+func setJSONGoType(t *Table, c *Column, typ string) {
+	name := typ
+	for {
+		if strings.HasPrefix(name, "*") || strings.HasPrefix(name, "[]") {
+			name = strings.TrimPrefix(strings.TrimPrefix(name, "*"), "[]")
+		} else if end := strings.IndexByte(name, ']'); strings.HasPrefix(name, "map[") && end > 0 {
+			name = name[end+1:]
+		} else {
+			break
+		}
+	}
+	prefix := typ[:len(typ)-len(name)]
+	if dot := strings.LastIndexByte(name, '.'); dot > 0 && !strings.ContainsAny(name, "{}") {
+		path := name[:dot]
+		if first := strings.SplitN(path, "/", 2)[0]; !strings.Contains(first, ".") && t.RelativePath != "" {
+			path = t.RelativePath + "/" + path
 		}
+		c.GoImport = path
+		typ = prefix + path[strings.LastIndexByte(path, '/')+1:] + name[dot:]
+	}
+	c.IsJSONType = true
+	c.GoColumnType = typ
+	c.GoBaseType = typ
+	c.GoConditionType = typ
+	c.BigType = 0
+}
 
-		c := &Column{
-			OrdinalPosition:           k,
-			ColumnName:                v.Name.String(),
-			DataType:                  v.Type.Type,
-			ColumnType:                ct,
-			ColumnComment:             comment,
-			NotNull:                   bool(v.Type.NotNull),
-			IsPrimaryKey:              false,
-			IsAutoIncrment:            bool(v.Type.Autoincrement),
-			IsDefaultCurrentTimestamp: dct,
-			GoColumnName:              "",
-			GoColumnType:              "",
-			BigType:                   0,
-			GoConditionType:           "",
+// setEnumType change the go type of enum and set column to the named type of the table,
+// enum is a string type with a const of every value, set is a bitset of the values
+func setEnumType(t *Table, c *Column) {
+	if len(c.EnumValues) == 0 || c.DataType == "set" && len(c.EnumValues) > 64 {
+		return
+	}
+	c.GoEnumType = t.GoTableName + c.GoColumnName
+	seen := map[string]bool{}
+	for i, v := range c.EnumValues {
+		var name string
+		for _, w := range strings.FieldsFunc(v.Value, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			name += strings.ToUpper(w[:1]) + w[1:]
+		}
+		if name == "" || seen[name] {
+			name += "V" + strconv.Itoa(i+1)
 		}
+		seen[name] = true
+		v.GoName = c.GoEnumType + name
+	}
+	c.GoColumnType = c.GoEnumType
+	c.GoBaseType = c.GoEnumType
+	c.GoConditionType = c.GoEnumType
+	c.ProtoType = c.GoEnumType
+	if c.DataType == "set" {
+		c.ProtoType = "repeated " + c.GoEnumType
+	}
+}
 
-		c.GoColumnName = GoCamelCase(c.ColumnName)
-		c.GoColumnType, c.BigType = MysqlToGoFieldType(c.DataType, c.ColumnType)
-		if strings.Contains(c.GoColumnType, "int") {
-			c.GoColumnType = "int64"
+// sortIndexes returns primary key first then unique keys then the others like mysql orders the keys of a table
+func sortIndexes(indexes []*Index) []*Index {
+	var res []*Index
+	for _, f := range []func(*Index) bool{
+		func(v *Index) bool { return v.Primary },
+		func(v *Index) bool { return !v.Primary && v.Unique },
+		func(v *Index) bool { return !v.Unique },
+	} {
+		for _, v := range indexes {
+			if f(v) {
+				res = append(res, v)
+			}
 		}
-		c.GoConditionType = c.GoColumnType
-		if c.BigType == bigtypeCompareTime {
-			c.GoConditionType = "string"
+	}
+	return res
+}
+
+// IndexFinders returns the finders of every leftmost prefix of the indexes.
+// full primary key is excluded which is covered by FindByPK
+func IndexFinders(indexes []*Index) []*Finder {
+	var finders []*Finder
+	seen := map[string]*Finder{}
+	for _, idx := range indexes {
+		if idx.Fulltext {
+			continue
 		}
-		res = append(res, c)
+		for i := range idx.Columns {
+			f := &Finder{
+				Unique:  idx.Unique && i == len(idx.Columns)-1,
+				Index:   idx,
+				Columns: idx.Columns[:i+1],
+			}
+			for _, c := range f.Columns {
+				f.GoName += c.GoColumnName
+			}
+			if idx.Primary && f.Unique {
+				continue
+			}
+			if exist, ok := seen[f.GoName]; ok {
+				// prefer the unique lookup when the same columns are covered by several indexes
+				if f.Unique && !exist.Unique {
+					*exist = *f
+				}
+				continue
+			}
+			seen[f.GoName] = f
+			finders = append(finders, f)
+		}
+	}
+	return finders
+}
+
+// IndexKeysets returns the keyset orders of the primary key and the indexes whose columns are NOT NULL,
+// the row comparison of the cursor can not compare NULL. It is empty if the table has no primary key
+func IndexKeysets(indexes []*Index, pks []*Column) []*Keyset {
+	if len(pks) == 0 {
+		return nil
+	}
+	keysets := []*Keyset{{Name: "PRIMARY", GoName: "PK", Columns: pks}}
+	seen := map[string]bool{}
+	for _, idx := range indexes {
+		if idx.Primary || idx.Fulltext {
+			continue
+		}
+		k := &Keyset{Name: idx.Name}
+		in := map[*Column]bool{}
+		for _, c := range idx.Columns {
+			if !c.NotNull || c.IsJSONType {
+				k = nil
+				break
+			}
+			k.GoName += c.GoColumnName
+			k.Columns = append(k.Columns, c)
+			in[c] = true
+		}
+		if k == nil || seen[k.GoName] {
+			continue
+		}
+		for _, c := range pks {
+			if !in[c] {
+				k.Columns = append(k.Columns, c)
+			}
+		}
+		seen[k.GoName] = true
+		keysets = append(keysets, k)
+	}
+	return keysets
+}
+
+// MysqlTable parse the file contains one CREATE TABLE statement
+func MysqlTable(db, path, relative string) (*Table, error) {
+	tables, err := MysqlTables(db, path, relative)
+	if err != nil {
+		return nil, err
+	}
+	return tables[0], nil
+}
+
+// MysqlTables parse all the CREATE TABLE statements of the file, such as a mysqldump --no-data output
+func MysqlTables(db, path, relative string) ([]*Table, error) {
+	sql, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := ParseMysql(db, string(sql), relative)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("%s: no CREATE TABLE statement", path)
+	}
+	return tables, nil
+}
 
+// ParseMysql parse mysql DDL statements to tables, one table per CREATE TABLE statement and one read-only table
+// per CREATE VIEW statement, other statements such as SET, DROP TABLE IF EXISTS, LOCK TABLES are ignored
+func ParseMysql(db, sql, relative string) ([]*Table, error) {
+	s := &MysqlSchema{}
+	if _, err := s.Parse(sql); err != nil {
+		return nil, err
 	}
-	var primaryKey string
-	for _, v := range ddl.TableSpec.Indexes {
-		if v.Info.Primary {
-			if len(v.Columns) != 1 {
-				log.Fatal("primary key must be one column")
+	return s.Tables(db, relative)
+}
+
+// MysqlSchema collects the CREATE TABLE and CREATE VIEW statements of several files,
+// the columns of a view are inferred from the tables of all the files
+type MysqlSchema struct {
+	tables []*myTable
+	views  []*myView
+	seen   map[string]*myTable
+}
+
+// Parse add the tables and views of the DDL statements, returns the number of them
+func (s *MysqlSchema) Parse(sql string) (int, error) {
+	toks, err := lexSQL(sql, true)
+	if err != nil {
+		return 0, err
+	}
+	if s.seen == nil {
+		s.seen = map[string]*myTable{}
+	}
+	n := 0
+	for _, stmt := range splitStatements(toks) {
+		p := &tokenParser{toks: stmt}
+		if mysqlViewHead(p) {
+			v, err := mysqlCreateView(p)
+			if err != nil {
+				return 0, err
+			}
+			n++
+			// the later definition replaces the view, mysqldump defines a placeholder view before the real one
+			replaced := false
+			for i, exist := range s.views {
+				if exist.name == v.name {
+					s.views[i], replaced = v, true
+				}
 			}
-			primaryKey = v.Columns[0].Column.String()
+			if !replaced {
+				s.views = append(s.views, v)
+			}
+			continue
+		}
+		if !p.accept("CREATE", "TABLE") && !p.accept("CREATE", "TEMPORARY", "TABLE") {
+			continue
+		}
+		t, err := mysqlCreateTable(p)
+		if err != nil {
+			return 0, err
+		}
+		if s.seen[t.name] != nil {
+			return 0, fmt.Errorf("duplicate table %s", t.name)
+		}
+		n++
+		s.seen[t.name] = t
+		s.tables = append(s.tables, t)
+	}
+	return n, nil
+}
+
+// Tables build the tables in the order of the statements, then the views. The table of the same name
+// as a view declares the columns of the view, such as the stand-in table of mysqldump, it is not returned
+func (s *MysqlSchema) Tables(db, relative string) ([]*Table, error) {
+	companions := map[string]bool{}
+	for _, v := range s.views {
+		if s.seen[v.name] != nil {
+			companions[v.name] = true
+		}
+	}
+	var res []*Table
+	for _, t := range s.tables {
+		if companions[t.name] {
+			continue
+		}
+		table, err := t.build(db, relative)
+		if err != nil {
+			return nil, err
 		}
+		res = append(res, table)
+	}
+	known := map[string]*myTable{}
+	for k, v := range s.seen {
+		known[k] = v
 	}
-	for _, v := range res {
-		if v.ColumnName == primaryKey {
-			v.IsPrimaryKey = true
+	for _, v := range s.views {
+		t := known[v.name]
+		if t == nil {
+			columns, err := v.infer(known)
+			if err != nil {
+				return nil, err
+			}
+			t = &myTable{name: v.name, columns: columns}
+			// the later views may select from the view
+			known[v.name] = t
+		}
+		table, err := t.build(db, relative)
+		if err != nil {
+			return nil, err
 		}
-		v.ProtoType = GoTypeToProtoType(v.GoColumnType)
+		table.IsView = true
+		res = append(res, table)
 	}
 	return res, nil
 }
 
-func MysqlTable(db, path, relative string) *Table {
-	sql, err := ioutil.ReadFile(path)
+// build the table of the parsed columns and indexes
+func (t *myTable) build(db, relative string) (*Table, error) {
+	for _, c := range t.columns {
+		setColumnGoType(c, MysqlToGoFieldType)
+		if c.GoBaseType == DecimalType && c.Precision == 0 {
+			// DECIMAL is DECIMAL(10,0)
+			c.Precision = 10
+		}
+	}
+	for _, idx := range t.indexes {
+		if !idx.Primary {
+			continue
+		}
+		for _, c := range idx.Columns {
+			c.IsPrimaryKey = true
+			// primary key columns are implicitly NOT NULL
+			c.NotNull = true
+		}
+	}
+	for _, c := range t.columns {
+		c.ProtoType = GoTypeToProtoType(c.GoBaseType)
+	}
+	table, err := NewTable(db, t.name, t.comment, relative, t.columns, sortIndexes(t.indexes))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	stmt, err := sqlparser.ParseStrictDDL(trimTimeStampFunc(string(sql)))
+	table.Checks = t.checks
+	table.ForeignKeys = t.foreignKeys
+	return table, nil
+}
+
+// myTable table defined by the mysql CREATE TABLE statement
+type myTable struct {
+	name        string
+	comment     string
+	columns     []*Column
+	indexes     []*Index
+	checks      []*Check
+	foreignKeys []*ForeignKey
+}
+
+// mysqlCreateTable parse CREATE TABLE statement after the TABLE keyword,
+// table options and partition options are ignored
+func mysqlCreateTable(p *tokenParser) (*myTable, error) {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := mysqlName(p)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if p.is("LIKE") || p.is("(", "LIKE") {
+		return nil, fmt.Errorf("table %s: CREATE TABLE LIKE is not supported", name)
+	}
+	t := &myTable{name: name}
+	if err := p.expect("("); err != nil {
+		return nil, fmt.Errorf("table %s: %v", name, err)
 	}
-	ddl, ok := stmt.(*sqlparser.DDL)
-	if !ok {
-		log.Fatal("please check sql file statement is DDL ")
+	for !p.accept(")") {
+		if err := t.element(p); err != nil {
+			return nil, fmt.Errorf("table %s: %v", name, err)
+		}
+		if !p.accept(",") && !p.is(")") {
+			return nil, fmt.Errorf("table %s: %v", name, p.errorf("expect , or )"))
+		}
+	}
+	if len(t.columns) == 0 {
+		return nil, fmt.Errorf("table %s has no column", name)
 	}
-	if ddl.Action != sqlparser.CreateStr {
-		log.Fatal("please check sql file statement is DDL and action is create  ")
+	// table options, COMMENT of the partitions are not the table comment
+	for !p.eof() && !p.is("PARTITION") {
+		if !p.accept("COMMENT") {
+			p.next()
+			continue
+		}
+		p.accept("=")
+		if v := p.next(); v.kind == tokenString {
+			t.comment = v.text
+		}
 	}
-	tableName := ddl.NewName.Name.String()
+	return t, nil
+}
 
-	gotableName := GoCamelCase(tableName)
-	mytable := &Table{
-		Database:    db,
-		TableName:   tableName,
-		GoTableName: gotableName,
-		PackageName: strings.ToLower(gotableName),
+// mysqlName parse [db.]name and returns the name
+func mysqlName(p *tokenParser) (string, error) {
+	name, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	for p.accept(".") {
+		if name, err = p.ident(); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+func (t *myTable) element(p *tokenParser) error {
+	var constraint string
+	if p.accept("CONSTRAINT") && !p.is("PRIMARY") && !p.is("UNIQUE") && !p.is("FOREIGN") && !p.is("CHECK") {
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		constraint = name
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		return t.key(p, "", true, true, false)
+	case p.accept("UNIQUE"):
+		if !p.accept("INDEX") {
+			p.accept("KEY")
+		}
+		return t.key(p, constraint, false, true, false)
+	case p.accept("FULLTEXT"), p.accept("SPATIAL"):
+		if !p.accept("INDEX") {
+			p.accept("KEY")
+		}
+		return t.key(p, "", false, false, true)
+	case p.accept("INDEX"), p.accept("KEY"):
+		return t.key(p, "", false, false, false)
+	case p.accept("FOREIGN", "KEY"):
+		return t.foreignKey(p, constraint)
+	case p.accept("CHECK"):
+		check, err := mysqlCheck(p, constraint)
+		if err != nil {
+			return err
+		}
+		t.checks = append(t.checks, check)
+		return nil
+	}
+	return t.columnDefinition(p)
+}
+
+// foreignKey parse [index_name] (col_name,...) REFERENCES tbl_name (col_name,...) ... after FOREIGN KEY
+func (t *myTable) foreignKey(p *tokenParser, name string) error {
+	if !p.is("(") {
+		if _, err := p.ident(); err != nil {
+			return err
+		}
+	}
+	names, err := p.identList((*tokenParser).ident)
+	if err != nil {
+		return err
+	}
+	fk := &ForeignKey{Name: name}
+	for _, v := range names {
+		c := t.column(v)
+		if c == nil {
+			return fmt.Errorf("foreign key column %s doesn't exist in table", v)
+		}
+		fk.Columns = append(fk.Columns, c)
+	}
+	if err := p.expect("REFERENCES"); err != nil {
+		return err
+	}
+	if err := mysqlReferences(p, fk); err != nil {
+		return err
+	}
+	if len(fk.RefColumns) != len(fk.Columns) {
+		return fmt.Errorf("foreign key %s references %d columns of %s, want %d", name, len(fk.RefColumns), fk.RefTable, len(fk.Columns))
+	}
+	t.foreignKeys = append(t.foreignKeys, fk)
+	return nil
+}
+
+// key parse [index_name] [USING type] (key_part,...) [index_option] ...
+// the index contains functional key parts is skipped, finders can not use it
+func (t *myTable) key(p *tokenParser, name string, primary, unique, fulltext bool) error {
+	if !p.is("(") && !p.is("USING") {
+		n, err := p.ident()
+		if err != nil {
+			return err
+		}
+		name = n
+	}
+	if p.accept("USING") {
+		p.next()
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	var names []string
+	var functional bool
+	for {
+		if p.is("(") {
+			functional = true
+			if _, err := p.skipParens(); err != nil {
+				return err
+			}
+		} else {
+			c, err := p.ident()
+			if err != nil {
+				return err
+			}
+			names = append(names, c)
+			if p.is("(") {
+				// prefix length
+				if _, err := p.skipParens(); err != nil {
+					return err
+				}
+			}
+		}
+		if !p.accept("ASC") {
+			p.accept("DESC")
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return err
 	}
-	columns, err := MysqlColumn(ddl)
+	p.skipUntil(",", ")")
+	if functional {
+		return nil
+	}
+	return t.addIndex(name, names, primary, unique, fulltext)
+}
+
+func (t *myTable) addIndex(name string, columns []string, primary, unique, fulltext bool) error {
+	idx := &Index{Name: name, Primary: primary, Unique: unique || primary, Fulltext: fulltext}
+	for _, v := range columns {
+		c := t.column(v)
+		if c == nil {
+			return fmt.Errorf("key column %s doesn't exist in table", v)
+		}
+		idx.Columns = append(idx.Columns, c)
+	}
+	if primary {
+		for _, v := range t.indexes {
+			if v.Primary {
+				return fmt.Errorf("multiple primary key defined")
+			}
+		}
+		idx.Name = "PRIMARY"
+		idx.GoName = "Primary"
+		t.indexes = append(t.indexes, idx)
+		return nil
+	}
+	if idx.Name == "" {
+		// mysql use the first column name as the index name, _2 _3 is appended if it is used
+		idx.Name = columns[0]
+		for i := 2; t.index(idx.Name) != nil; i++ {
+			idx.Name = fmt.Sprintf("%s_%d", columns[0], i)
+		}
+	}
+	if t.index(idx.Name) != nil {
+		return fmt.Errorf("duplicate key name %s", idx.Name)
+	}
+	idx.GoName = GoCamelCase(idx.Name)
+	t.indexes = append(t.indexes, idx)
+	return nil
+}
+
+func (t *myTable) index(name string) *Index {
+	for _, v := range t.indexes {
+		if strings.EqualFold(v.Name, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func (t *myTable) column(name string) *Column {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.ColumnName, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// mysqlCheck parse (expr) [[NOT] ENFORCED] after the CHECK keyword
+func mysqlCheck(p *tokenParser, name string) (*Check, error) {
+	expr, err := p.skipParens()
+	if err != nil {
+		return nil, err
+	}
+	check := &Check{Name: name, Expression: expr}
+	if p.accept("NOT", "ENFORCED") {
+		check.NotEnforced = true
+	}
+	p.accept("ENFORCED")
+	return check, nil
+}
+
+func (t *myTable) columnDefinition(p *tokenParser) error {
+	name, err := p.ident()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if t.column(name) != nil {
+		return fmt.Errorf("duplicate column name %s", name)
+	}
+	dt, ct, err := mysqlType(p)
+	if err != nil {
+		return fmt.Errorf("column %s: %v", name, err)
+	}
+	c := &Column{
+		OrdinalPosition: len(t.columns),
+		ColumnName:      name,
+		DataType:        dt,
+		ColumnType:      ct,
+	}
+	t.columns = append(t.columns, c)
+	if err := t.columnAttributes(p, c); err != nil {
+		return fmt.Errorf("column %s: %v", name, err)
+	}
+	return nil
+}
+
+// columnAttributes parse the attributes after the data type of the column definition
+func (t *myTable) columnAttributes(p *tokenParser, c *Column) error {
+	for !p.eof() && !p.is(",") && !p.is(")") {
+		var constraint string
+		if p.accept("CONSTRAINT") && !p.is("CHECK") {
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			constraint = name
+		}
+		switch {
+		case p.accept("NOT", "NULL"):
+			c.NotNull = true
+		case p.accept("NULL"):
+		case p.accept("DEFAULT"):
+			def, err := mysqlDefault(p)
+			if err != nil {
+				return err
+			}
+			c.ColumnDefault = mysqlDefaultValue(c, def)
+			c.IsDefaultCurrentTimestamp = strings.HasPrefix(c.ColumnDefault, "CURRENT_TIMESTAMP")
+		case p.accept("ON", "UPDATE"):
+			def, err := mysqlDefault(p)
+			if err != nil {
+				return err
+			}
+			c.IsOnUpdateCurrentTimestamp = strings.HasPrefix(mysqlDefaultValue(c, def), "CURRENT_TIMESTAMP")
+		case p.accept("AUTO_INCREMENT"):
+			c.IsAutoIncrment = true
+		case p.accept("PRIMARY", "KEY"), p.accept("KEY"):
+			if err := t.addIndex("", []string{c.ColumnName}, true, true, false); err != nil {
+				return err
+			}
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			if err := t.addIndex("", []string{c.ColumnName}, false, true, false); err != nil {
+				return err
+			}
+		case p.accept("COMMENT"):
+			comment := p.next()
+			if comment.kind != tokenString {
+				return p.errorf("expect comment string")
+			}
+			c.ColumnComment = comment.text
+		case p.accept("GENERATED", "ALWAYS", "AS"), p.accept("AS"):
+			expr, err := p.skipParens()
+			if err != nil {
+				return err
+			}
+			c.IsGenerated = true
+			c.GenerationExpression = expr
+		case p.accept("VIRTUAL"):
+		case p.accept("STORED"):
+			c.IsStored = true
+		case p.accept("VISIBLE"):
+		case p.accept("INVISIBLE"):
+			c.IsInvisible = true
+		case p.accept("COLLATE"), p.accept("CHARACTER", "SET"), p.accept("CHARSET"),
+			p.accept("COLUMN_FORMAT"), p.accept("STORAGE"), p.accept("SRID"):
+			p.next()
+		case p.accept("ENGINE_ATTRIBUTE"), p.accept("SECONDARY_ENGINE_ATTRIBUTE"):
+			p.accept("=")
+			p.next()
+		case p.accept("REFERENCES"):
+			// mysql parses and ignores the inline REFERENCES
+			if err := mysqlReferences(p, &ForeignKey{}); err != nil {
+				return err
+			}
+		case p.accept("CHECK"):
+			check, err := mysqlCheck(p, constraint)
+			if err != nil {
+				return err
+			}
+			c.Checks = append(c.Checks, check)
+		default:
+			return p.errorf("unexpected column attribute")
+		}
+	}
+	return nil
+}
+
+// mysqlReferences parse tbl_name (key_part,...) [MATCH type] [ON DELETE option] [ON UPDATE option] after REFERENCES
+// into the referenced table, columns and actions of fk
+func mysqlReferences(p *tokenParser, fk *ForeignKey) error {
+	var err error
+	if fk.RefTable, err = mysqlName(p); err != nil {
+		return err
+	}
+	if fk.RefColumns, err = p.identList((*tokenParser).ident); err != nil {
+		return err
+	}
+	for {
+		switch {
+		case p.accept("MATCH"):
+			p.next()
+		case p.accept("ON", "DELETE"):
+			if fk.OnDelete, err = referentialAction(p); err != nil {
+				return err
+			}
+		case p.accept("ON", "UPDATE"):
+			if fk.OnUpdate, err = referentialAction(p); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// referentialAction parse CASCADE, SET NULL, SET DEFAULT, RESTRICT or NO ACTION after ON DELETE or ON UPDATE
+func referentialAction(p *tokenParser) (string, error) {
+	for _, v := range [][]string{{"CASCADE"}, {"RESTRICT"}, {"SET", "NULL"}, {"SET", "DEFAULT"}, {"NO", "ACTION"}} {
+		if p.accept(v...) {
+			return strings.Join(v, " "), nil
+		}
+	}
+	return "", p.errorf("expect CASCADE, SET NULL, SET DEFAULT, RESTRICT or NO ACTION")
+}
+
+// mysqlTypeAlias the synonym types are stored as the canonical type by mysql
+var mysqlTypeAlias = map[string]string{
+	"integer": "int", "int1": "tinyint", "int2": "smallint", "int3": "mediumint", "int4": "int", "int8": "bigint",
+	"middleint": "mediumint", "dec": "decimal", "numeric": "decimal", "fixed": "decimal", "real": "double",
+	"float4": "float", "float8": "double", "character": "char", "nchar": "char", "nvarchar": "varchar",
+}
+
+// mysqlType parse the data type, returns the data type and the column type like information_schema.COLUMNS
+// such as int and int(10) unsigned, enum and enum('a','b')
+func mysqlType(p *tokenParser) (string, string, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", "", p.errorf("expect data type")
+	}
+	dt := strings.ToLower(t.text)
+	switch {
+	case dt == "double":
+		p.accept("PRECISION")
+	case dt == "national":
+		p.accept("CHARACTER")
+		p.accept("CHAR")
+		dt = "char"
+		if p.accept("VARYING") || p.accept("VARCHAR") {
+			dt = "varchar"
+		}
+	case dt == "long":
+		dt = "mediumtext"
+		if p.accept("VARBINARY") {
+			dt = "mediumblob"
+		} else {
+			p.accept("VARCHAR")
+		}
+	case dt == "char" || dt == "character":
+		if p.accept("VARYING") {
+			dt = "varchar"
+		}
+	}
+	if v, ok := mysqlTypeAlias[dt]; ok {
+		dt = v
+	}
+	ct := dt
+	if p.is("(") {
+		start := p.pos
+		if _, err := p.skipParens(); err != nil {
+			return "", "", err
+		}
+		// the raw text without spaces like information_schema, enum('a','b') decimal(10,2)
+		var args strings.Builder
+		for _, v := range p.toks[start+1 : p.pos-1] {
+			args.WriteString(v.raw)
+		}
+		ct += "(" + args.String() + ")"
 	}
-	if len(columns) <= 0 {
-		log.Fatal("schema or table not exist")
+	for {
+		switch {
+		case p.accept("UNSIGNED"):
+			if !strings.Contains(ct, " unsigned") {
+				ct += " unsigned"
+			}
+		case p.accept("ZEROFILL"):
+			// zerofill implies unsigned
+			if !strings.Contains(ct, " unsigned") {
+				ct += " unsigned"
+			}
+			ct += " zerofill"
+		case p.accept("SIGNED"), p.accept("BINARY"), p.accept("ASCII"), p.accept("UNICODE"):
+		case p.accept("CHARACTER", "SET"), p.accept("CHARSET"), p.accept("COLLATE"):
+			p.next()
+		default:
+			return dt, ct, nil
+		}
+	}
+}
+
+// mysqlDefaultValue normalize the default value like information_schema.COLUMNS shows:
+// NULL is empty, NOW() LOCALTIME are CURRENT_TIMESTAMP, quoted number of numeric column is unquoted
+func mysqlDefaultValue(c *Column, def string) string {
+	lower := strings.ToLower(def)
+	if lower == "null" {
+		return ""
+	}
+	for _, v := range []string{"current_timestamp", "now", "localtimestamp", "localtime"} {
+		if lower == v || strings.HasPrefix(lower, v+"(") {
+			fsp := strings.TrimPrefix(lower, v)
+			if fsp == "()" {
+				fsp = ""
+			}
+			return "CURRENT_TIMESTAMP" + fsp
+		}
+	}
+	if isNumericType(c.DataType) && len(def) > 1 && def[0] == '\'' {
+		// int DEFAULT '0'
+		return strings.ReplaceAll(def[1:len(def)-1], "''", "'")
+	}
+	return def
+}
+
+// isNumericType the mysql numeric data type
+func isNumericType(dt string) bool {
+	switch dt {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "decimal", "float", "double":
+		return true
+	}
+	return false
+}
+
+// quoteString quote the string literal, the quote is escaped by doubling it
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// mysqlDefault consume the default value: literal, -1, _utf8mb4'x', b'01', CURRENT_TIMESTAMP(3), (expr),
+// string literal is requoted by quoteString
+func mysqlDefault(p *tokenParser) (string, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenEOF:
+		return "", p.errorf("expect default value")
+	case t.kind == tokenPunct && t.text == "(":
+		expr, err := p.skipParens()
+		return "(" + expr + ")", err
+	case t.kind == tokenPunct && (t.text == "-" || t.text == "+"):
+		p.next()
+		return t.text + p.next().raw, nil
+	case t.kind == tokenIdent && strings.HasPrefix(t.text, "_") && p.peekN(1).kind == tokenString:
+		// charset introducer _utf8mb4'x'
+		p.next()
+		return quoteString(p.next().text), nil
+	case t.kind == tokenIdent && p.peekN(1).kind == tokenString:
+		// bit and hex literal b'01' x'1f'
+		p.next()
+		return t.raw + p.next().raw, nil
+	case t.kind == tokenString:
+		p.next()
+		return quoteString(t.text), nil
+	case t.kind == tokenIdent && p.peekN(1).kind == tokenPunct && p.peekN(1).text == "(":
+		p.next()
+		args, err := p.skipParens()
+		return t.raw + "(" + args + ")", err
+	}
+	p.next()
+	return t.raw, nil
+}
+
+// NewTable build the table from its columns and indexes, primary key columns are taken from the PRIMARY index,
+// the @crud: directives in the table and column comments are applied
+func NewTable(db, tableName, comment, relative string, columns []*Column, indexes []*Index) (*Table, error) {
+	gotableName := GoCamelCase(tableName)
+	mytable := &Table{
+		Dialect:      DialectMySQL,
+		Database:     db,
+		TableName:    tableName,
+		TableComment: comment,
+		ProtoName:    tableName,
+		GoTableName:  gotableName,
+		PackageName:  strings.ToLower(gotableName),
+		RelativePath: relative,
+		OutputDir:    "crud",
 	}
 	mytable.Fields = columns
+	mytable.Indexes = indexes
+	for _, v := range indexes {
+		if v.Primary {
+			mytable.PrimaryKeys = v.Columns
+		}
+	}
+	if err := setTableDirectives(mytable, comment); err != nil {
+		return nil, err
+	}
 	for _, v := range columns {
-		if v.IsPrimaryKey {
-			mytable.PrimaryKey = v
+		v.JSONName = v.ColumnName
+		v.ProtoName = v.ColumnName
+		v.Comment = stripDirectives(v.ColumnComment)
+		if err := setColumnDirectives(mytable, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := mytable.derive(); err != nil {
+		return nil, err
+	}
+	return mytable, nil
+}
+
+// derive the finders, enum types and where columns of the table which depend on the go names of the table and columns
+func (t *Table) derive() error {
+	names := map[string]string{}
+	for _, v := range t.Fields {
+		if exist, ok := names[v.GoColumnName]; ok {
+			return fmt.Errorf("table %s: column %s and %s have the same go name %s", t.TableName, exist, v.ColumnName, v.GoColumnName)
 		}
+		names[v.GoColumnName] = v.ColumnName
+	}
+	t.Finders = IndexFinders(t.Indexes)
+	t.PrimaryKey = nil
+	if len(t.PrimaryKeys) == 1 {
+		t.PrimaryKey = t.PrimaryKeys[0]
+	}
+	t.ImportTime = false
+	for _, v := range t.Fields {
 		if v.GoColumnType == "time.Time" {
-			mytable.ImportTime = true
+			t.ImportTime = true
+		}
+		if v.IsGenerated {
+			// the value of the generated column is computed by the database, it can not be set
+			v.IsReadOnly = true
+		}
+		setEnumType(t, v)
+		setJSONType(t, v)
+	}
+	if t.Track {
+		if err := t.checkTrackMethods(); err != nil {
+			return err
 		}
-		v.ProtoType = GoTypeToProtoType(v.GoColumnType)
 	}
+	t.Keysets = IndexKeysets(t.Indexes, t.PrimaryKeys)
+	t.GenerateWhereCol = whereColumns(t.Fields)
+	return nil
+}
 
-	mytable.GenerateWhereCol = mytable.Fields
-	mytable.RelativePath = relative
-	return mytable
+// checkTrackMethods reports the field which has the name of a method of the @crud:track model,
+// the go struct can not have a field and a method of the same name
+func (t *Table) checkTrackMethods() error {
+	methods := map[string]bool{"Save": true, "ChangedColumns": true, "ClearChanges": true}
+	for _, v := range t.Fields {
+		if !v.IsSkip && !v.IsPrimaryKey && !v.IsReadOnly {
+			methods["Set"+v.GoColumnName] = true
+		}
+	}
+	for _, v := range t.Fields {
+		if !v.IsSkip && methods[v.GoColumnName] {
+			return fmt.Errorf("table %s: go name %s of column %s is a method of the @crud:track model, rename it by @crud:name", t.TableName, v.GoColumnName, v.ColumnName)
+		}
+	}
+	return nil
+}
 
+// SetExactInt keeps the exact go type of the integer columns: int8 uint16 uint64..., tinyint(1) is bool,
+// all integer columns are int64 by default. It must be called before SetNullStyle
+func SetExactInt(t *Table) {
+	goFieldType := MysqlToGoFieldType
+	if t.Dialect == DialectPostgres {
+		goFieldType = PostgresToGoFieldType
+	}
+	for _, v := range t.Fields {
+		if v.GoBaseType != "int64" || v.IsJSONType || v.typeSet {
+			continue
+		}
+		typ, _ := goFieldType(v.DataType, v.ColumnType)
+		if v.ColumnType == "tinyint(1)" {
+			typ = "bool"
+		}
+		v.GoColumnType = typ
+		v.GoBaseType = typ
+		v.GoConditionType = typ
+		v.ProtoType = GoTypeToProtoType(typ)
+	}
+}
+
+// SetNullStyle change the go type of nullable columns to sql.Null* or pointer type according to the style
+func SetNullStyle(t *Table, style string) {
+	t.ImportTime = false
+	t.ImportSQL = false
+	for _, v := range t.Fields {
+		switch {
+		case v.NotNull, v.IsJSONType:
+		case v.GoEnumType != "" && style == NullStyleSQL:
+			// sql.Null* can not hold the named type, enum is a sql.NullString and set is a pointer
+			v.GoColumnType = "sql.NullString"
+			if v.DataType == "set" {
+				v.GoColumnType = "*" + v.GoEnumType
+			}
+		default:
+			v.GoColumnType = GoNullFieldType(v.GoBaseType, style)
+		}
+		if strings.HasSuffix(v.GoColumnType, "time.Time") {
+			t.ImportTime = true
+		}
+		if strings.HasPrefix(v.GoColumnType, "sql.") {
+			t.ImportSQL = true
+		}
+	}
 }
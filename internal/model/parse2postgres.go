@@ -0,0 +1,763 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// PostgresTables parse the CREATE TABLE statements of the .sql file with postgres syntax,
+// CREATE INDEX, COMMENT ON COLUMN and ALTER TABLE ADD PRIMARY KEY/UNIQUE/FOREIGN KEY of the tables are applied too
+func PostgresTables(db, path, relative string) ([]*Table, error) {
+	sql, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := ParsePostgres(db, string(sql), relative)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("%s: no CREATE TABLE statement", path)
+	}
+	return tables, nil
+}
+
+// ParsePostgres parse postgres DDL statements to tables, statements other than
+// CREATE TABLE, CREATE INDEX, COMMENT ON COLUMN, ALTER TABLE ADD CONSTRAINT are ignored
+func ParsePostgres(db, sql, relative string) ([]*Table, error) {
+	toks, err := lexSQL(sql, false)
+	if err != nil {
+		return nil, err
+	}
+	pg := &pgSchema{tables: map[string]*pgTable{}, enums: map[string][]string{}}
+	for _, stmt := range splitStatements(toks) {
+		if err := pg.statement(&tokenParser{toks: stmt}); err != nil {
+			return nil, err
+		}
+	}
+	var res []*Table
+	for _, v := range pg.order {
+		t := pg.tables[v]
+		for _, c := range t.columns {
+			values, enum := pg.enums[c.DataType]
+			if enum {
+				c.DataType = "enum"
+			}
+			setColumnGoType(c, PostgresToGoFieldType)
+			for _, v := range values {
+				c.EnumValues = append(c.EnumValues, &EnumValue{Value: v})
+			}
+		}
+		for _, idx := range t.indexes {
+			if !idx.Primary {
+				continue
+			}
+			for _, c := range idx.Columns {
+				c.IsPrimaryKey = true
+				c.NotNull = true
+			}
+		}
+		for _, c := range t.columns {
+			c.ProtoType = GoTypeToProtoType(c.GoBaseType)
+		}
+		table, err := NewTable(db, t.name, t.comment, relative, t.columns, sortIndexes(t.indexes))
+		if err != nil {
+			return nil, err
+		}
+		table.Dialect = DialectPostgres
+		table.Checks = t.checks
+		table.ForeignKeys = t.foreignKeys
+		res = append(res, table)
+	}
+	return res, nil
+}
+
+// PostgresToGoFieldType returns the go type and the where type of the postgres data type
+func PostgresToGoFieldType(dt, ct string) (string, int) {
+	if strings.HasSuffix(ct, "[]") {
+		// array is scanned as its text representation
+		return "string", 0
+	}
+	switch dt {
+	case "smallint", "int2", "smallserial", "serial2":
+		return "int16", bigtypeCompare
+	case "integer", "int", "int4", "serial", "serial4":
+		return "int32", bigtypeCompare
+	case "bigint", "int8", "bigserial", "serial8":
+		return "int64", bigtypeCompare
+	case "real", "float4":
+		return "float32", bigtypeCompare
+	case "double precision", "float8", "float":
+		return "float64", bigtypeCompare
+	case "numeric", "decimal":
+		return DecimalType, bigtypeCompare
+	case "boolean", "bool":
+		return "bool", 0
+	case "character varying", "varchar", "character", "char", "bpchar", "citext":
+		return "string", bigtypeCompareString
+	case "text", "json", "jsonb", "xml":
+		return "string", 0
+	case "uuid", "inet", "cidr", "macaddr", "money", "interval", "enum",
+		"time", "time without time zone", "time with time zone", "timetz":
+		return "string", bigtypeCompare
+	case "bytea":
+		return "[]byte", 0
+	case "timestamp", "timestamp without time zone", "timestamp with time zone", "timestamptz", "date":
+		return "time.Time", bigtypeCompareTime
+	default:
+		return "UNKNOWN", 0
+	}
+}
+
+// pgSchema tables defined in the postgres DDL file
+type pgSchema struct {
+	tables map[string]*pgTable
+	order  []string
+	enums  map[string][]string // CREATE TYPE x AS ENUM ('a', 'b')
+}
+
+type pgTable struct {
+	name        string
+	comment     string
+	columns     []*Column
+	indexes     []*Index
+	checks      []*Check
+	foreignKeys []*ForeignKey
+}
+
+func (s *pgSchema) statement(p *tokenParser) error {
+	switch {
+	case p.is("CREATE", "TYPE"):
+		return s.createType(p)
+	case p.is("CREATE"):
+		p.next()
+		p.accept("UNLOGGED")
+		p.accept("TEMPORARY")
+		p.accept("TEMP")
+		switch {
+		case p.accept("TABLE"):
+			return s.createTable(p)
+		case p.accept("UNIQUE", "INDEX"):
+			return s.createIndex(p, true)
+		case p.accept("INDEX"):
+			return s.createIndex(p, false)
+		}
+	case p.is("COMMENT", "ON", "COLUMN"):
+		return s.comment(p)
+	case p.is("COMMENT", "ON", "TABLE"):
+		return s.tableComment(p)
+	case p.is("ALTER", "TABLE"):
+		return s.alterTable(p)
+	}
+	return nil
+}
+
+func (s *pgSchema) table(name string) (*pgTable, error) {
+	t, ok := s.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("table %s is not created before it is used", name)
+	}
+	return t, nil
+}
+
+// qualifiedName parse [schema.]name and returns the name
+func (s *pgSchema) qualifiedName(p *tokenParser) (string, error) {
+	name, err := pgIdent(p)
+	if err != nil {
+		return "", err
+	}
+	for p.accept(".") {
+		if name, err = pgIdent(p); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// pgIdent unquoted identifier is folded to lower case
+func pgIdent(p *tokenParser) (string, error) {
+	quoted := p.peek().kind == tokenQuoted
+	name, err := p.ident()
+	if err != nil || quoted {
+		return name, err
+	}
+	return strings.ToLower(name), nil
+}
+
+func (s *pgSchema) createType(p *tokenParser) error {
+	p.accept("CREATE", "TYPE")
+	name, err := s.qualifiedName(p)
+	if err != nil {
+		return err
+	}
+	if !p.accept("AS", "ENUM") {
+		return nil
+	}
+	var values []string
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.accept(")") {
+		t := p.next()
+		if t.kind != tokenString {
+			return p.errorf("expect enum value")
+		}
+		values = append(values, t.text)
+		p.accept(",")
+	}
+	s.enums[name] = values
+	return nil
+}
+
+func (s *pgSchema) createTable(p *tokenParser) error {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := s.qualifiedName(p)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.tables[name]; ok {
+		return fmt.Errorf("table %s is created twice", name)
+	}
+	t := &pgTable{name: name}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.accept(")") {
+		if err := s.tableElement(p, t); err != nil {
+			return fmt.Errorf("table %s: %v", name, err)
+		}
+		if !p.accept(",") && !p.is(")") {
+			return fmt.Errorf("table %s: %v", name, p.errorf("expect , or )"))
+		}
+	}
+	s.tables[name] = t
+	s.order = append(s.order, name)
+	return nil
+}
+
+func (s *pgSchema) tableElement(p *tokenParser, t *pgTable) error {
+	var constraint string
+	if p.accept("CONSTRAINT") {
+		name, err := pgIdent(p)
+		if err != nil {
+			return err
+		}
+		constraint = name
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		return t.addKey(p, constraint, true)
+	case p.accept("UNIQUE"):
+		return t.addKey(p, constraint, false)
+	case p.accept("CHECK"):
+		check, err := pgCheck(p, constraint)
+		if err != nil {
+			return err
+		}
+		t.checks = append(t.checks, check)
+		return nil
+	case p.accept("FOREIGN", "KEY"):
+		return s.foreignKey(p, t, constraint)
+	case constraint != "" || p.is("EXCLUDE") || p.is("LIKE"):
+		p.skipUntil(",", ")")
+		return nil
+	}
+	return s.column(p, t)
+}
+
+// addKey add PRIMARY KEY (cols) or UNIQUE (cols) constraint
+func (t *pgTable) addKey(p *tokenParser, name string, primary bool) error {
+	p.accept("NULLS", "NOT", "DISTINCT")
+	p.accept("NULLS", "DISTINCT")
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	var names []string
+	for {
+		c, err := pgIdent(p)
+		if err != nil {
+			return err
+		}
+		names = append(names, c)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+	// INCLUDE (...) WITH (...) USING INDEX TABLESPACE x
+	p.skipUntil(",", ")")
+	return t.addIndex(name, names, primary, true, false)
+}
+
+func (t *pgTable) addIndex(name string, columns []string, primary, unique, fulltext bool) error {
+	idx := &Index{Name: name, Primary: primary, Unique: unique || primary, Fulltext: fulltext}
+	for _, v := range columns {
+		c := t.column(v)
+		if c == nil {
+			return fmt.Errorf("index column %s is not exist", v)
+		}
+		idx.Columns = append(idx.Columns, c)
+	}
+	if idx.Name == "" {
+		// postgres default name of the constraint and index
+		suffix := "_idx"
+		switch {
+		case primary:
+			suffix = "_pkey"
+		case unique:
+			suffix = "_key"
+		}
+		idx.Name = t.name + "_" + strings.Join(columns, "_") + suffix
+		if primary {
+			idx.Name = t.name + suffix
+		}
+	}
+	idx.GoName = GoCamelCase(idx.Name)
+	if primary {
+		idx.GoName = "Primary"
+		for _, v := range t.indexes {
+			if v.Primary {
+				return fmt.Errorf("multiple primary keys for table %s are not allowed", t.name)
+			}
+		}
+	}
+	t.indexes = append(t.indexes, idx)
+	return nil
+}
+
+func (t *pgTable) column(name string) *Column {
+	for _, c := range t.columns {
+		if c.ColumnName == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *pgSchema) column(p *tokenParser, t *pgTable) error {
+	name, err := pgIdent(p)
+	if err != nil {
+		return err
+	}
+	if t.column(name) != nil {
+		return fmt.Errorf("column %s is specified more than once", name)
+	}
+	dt, ct, err := pgType(p)
+	if err != nil {
+		return fmt.Errorf("column %s: %v", name, err)
+	}
+	c := &Column{
+		OrdinalPosition: len(t.columns),
+		ColumnName:      name,
+		DataType:        dt,
+		ColumnType:      ct,
+		IsAutoIncrment:  contains([]string{"smallserial", "serial", "bigserial", "serial2", "serial4", "serial8"}, dt),
+	}
+	t.columns = append(t.columns, c)
+	for !p.eof() && !p.is(",") && !p.is(")") {
+		var constraint string
+		if p.accept("CONSTRAINT") {
+			if constraint, err = pgIdent(p); err != nil {
+				return err
+			}
+		}
+		switch {
+		case p.accept("NOT", "NULL"):
+			c.NotNull = true
+		case p.accept("NULL"):
+		case p.accept("DEFAULT"):
+			c.ColumnDefault = pgDefault(p)
+			def := strings.ToLower(c.ColumnDefault)
+			switch {
+			case strings.HasPrefix(def, "nextval("):
+				c.IsAutoIncrment = true
+				c.ColumnDefault = ""
+			case strings.HasPrefix(def, "now()"), strings.HasPrefix(def, "current_timestamp"),
+				strings.HasPrefix(def, "localtimestamp"), strings.HasPrefix(def, "transaction_timestamp()"):
+				c.IsDefaultCurrentTimestamp = true
+			}
+		case p.accept("PRIMARY", "KEY"):
+			if err := t.addIndex(constraint, []string{name}, true, true, false); err != nil {
+				return err
+			}
+		case p.accept("UNIQUE"):
+			if err := t.addIndex(constraint, []string{name}, false, true, false); err != nil {
+				return err
+			}
+		case p.accept("GENERATED"):
+			if p.accept("ALWAYS", "AS", "(") || p.accept("AS", "(") {
+				// generated column
+				p.pos--
+				expr, err := p.skipParens()
+				if err != nil {
+					return err
+				}
+				c.IsGenerated = true
+				c.GenerationExpression = expr
+				c.IsStored = p.accept("STORED")
+				continue
+			}
+			// GENERATED { ALWAYS | BY DEFAULT } AS IDENTITY [ ( sequence_options ) ]
+			if !p.accept("ALWAYS") && !p.accept("BY", "DEFAULT") {
+				return p.errorf("expect ALWAYS or BY DEFAULT")
+			}
+			if err := p.expect("AS", "IDENTITY"); err != nil {
+				return err
+			}
+			if p.is("(") {
+				if _, err := p.skipParens(); err != nil {
+					return err
+				}
+			}
+			c.IsAutoIncrment = true
+		case p.accept("REFERENCES"):
+			fk := &ForeignKey{Name: constraint, Columns: []*Column{c}}
+			if err := s.references(p, fk); err != nil {
+				return err
+			}
+			t.foreignKeys = append(t.foreignKeys, fk)
+		case p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"):
+		case p.accept("INITIALLY"):
+			p.next()
+		case p.accept("CHECK"):
+			check, err := pgCheck(p, constraint)
+			if err != nil {
+				return err
+			}
+			c.Checks = append(c.Checks, check)
+		case p.accept("COLLATE"):
+			if _, err := s.qualifiedName(p); err != nil {
+				return err
+			}
+		default:
+			return p.errorf("unexpected column constraint")
+		}
+	}
+	return nil
+}
+
+// pgCheck parse (expr) [NO INHERIT] [NOT VALID] after the CHECK keyword
+func pgCheck(p *tokenParser, name string) (*Check, error) {
+	expr, err := p.skipParens()
+	if err != nil {
+		return nil, err
+	}
+	p.accept("NO", "INHERIT")
+	return &Check{Name: name, Expression: expr, NotEnforced: p.accept("NOT", "VALID")}, nil
+}
+
+// foreignKey parse ( column_name [, ... ] ) REFERENCES reftable [ ( refcolumn [, ... ] ) ] ... after FOREIGN KEY
+func (s *pgSchema) foreignKey(p *tokenParser, t *pgTable, name string) error {
+	names, err := p.identList(pgIdent)
+	if err != nil {
+		return err
+	}
+	fk := &ForeignKey{Name: name}
+	for _, v := range names {
+		c := t.column(v)
+		if c == nil {
+			return fmt.Errorf("foreign key column %s is not exist", v)
+		}
+		fk.Columns = append(fk.Columns, c)
+	}
+	if err := p.expect("REFERENCES"); err != nil {
+		return err
+	}
+	if err := s.references(p, fk); err != nil {
+		return err
+	}
+	if len(fk.RefColumns) > 0 && len(fk.RefColumns) != len(fk.Columns) {
+		return fmt.Errorf("foreign key %s references %d columns of %s, want %d", name, len(fk.RefColumns), fk.RefTable, len(fk.Columns))
+	}
+	t.foreignKeys = append(t.foreignKeys, fk)
+	// NOT VALID DEFERRABLE INITIALLY DEFERRED
+	p.skipUntil(",", ")")
+	return nil
+}
+
+// references parse reftable [ ( refcolumn ) ] [ MATCH type ] [ ON DELETE action ] [ ON UPDATE action ] after REFERENCES
+// into the referenced table, columns and actions of fk, the columns are empty for the primary key of reftable
+func (s *pgSchema) references(p *tokenParser, fk *ForeignKey) error {
+	var err error
+	if fk.RefTable, err = s.qualifiedName(p); err != nil {
+		return err
+	}
+	if p.is("(") {
+		if fk.RefColumns, err = p.identList(pgIdent); err != nil {
+			return err
+		}
+	}
+	for {
+		switch {
+		case p.accept("MATCH"):
+			p.next()
+		case p.accept("ON", "DELETE"):
+			if fk.OnDelete, err = pgReferentialAction(p); err != nil {
+				return err
+			}
+		case p.accept("ON", "UPDATE"):
+			if fk.OnUpdate, err = pgReferentialAction(p); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// pgReferentialAction parse the referential action, SET NULL and SET DEFAULT of ON DELETE may have the column list
+func pgReferentialAction(p *tokenParser) (string, error) {
+	action, err := referentialAction(p)
+	if err != nil || !strings.HasPrefix(action, "SET ") || !p.is("(") {
+		return action, err
+	}
+	columns, err := p.identList(pgIdent)
+	if err != nil {
+		return "", err
+	}
+	return action + " (" + strings.Join(columns, ", ") + ")", nil
+}
+
+// pgType parse the data type, returns the canonical type name and the full type
+func pgType(p *tokenParser) (string, string, error) {
+	name, err := pgIdent(p)
+	if err != nil {
+		return "", "", err
+	}
+	for p.accept(".") {
+		// schema qualified type public.citext
+		if name, err = pgIdent(p); err != nil {
+			return "", "", err
+		}
+	}
+	dt := name
+	switch dt {
+	case "double":
+		if err := p.expect("PRECISION"); err != nil {
+			return "", "", err
+		}
+		dt = "double precision"
+	case "character", "char", "bit":
+		if p.accept("VARYING") {
+			dt = map[string]string{"character": "character varying", "char": "character varying", "bit": "bit varying"}[dt]
+		}
+	}
+	ct := dt
+	if p.is("(") {
+		args, err := p.skipParens()
+		if err != nil {
+			return "", "", err
+		}
+		ct += "(" + args + ")"
+	}
+	if dt == "timestamp" || dt == "time" {
+		switch {
+		case p.accept("WITH", "TIME", "ZONE"):
+			dt += " with time zone"
+		case p.accept("WITHOUT", "TIME", "ZONE"):
+			dt += " without time zone"
+		}
+		ct = dt
+	}
+	for p.is("[") {
+		p.skipUntil("]")
+		p.next()
+		ct += "[]"
+	}
+	if p.accept("ARRAY") {
+		ct += "[]"
+	}
+	return dt, ct, nil
+}
+
+// pgDefault consume the default expression: literals, function calls, parenthesized expressions,
+// casts and binary operators between them
+func pgDefault(p *tokenParser) string {
+	start := p.pos
+	for {
+		if !p.accept("-") {
+			p.accept("+")
+		}
+		switch t := p.next(); {
+		case t.kind == tokenPunct && t.text == "(":
+			p.pos--
+			p.skipParens()
+		case t.kind == tokenIdent && p.is("("):
+			p.skipParens()
+		case t.kind == tokenIdent && p.peek().kind == tokenString:
+			// typed literal E'\n' interval '1 day'
+			p.next()
+		}
+		for p.accept("::") {
+			pgType(p)
+		}
+		switch {
+		case p.accept("||"), p.accept("+"), p.accept("-"), p.accept("*"), p.accept("/"):
+		default:
+			return joinTokens(p.toks[start:p.pos])
+		}
+	}
+}
+
+func (s *pgSchema) createIndex(p *tokenParser, unique bool) error {
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	var name string
+	if !p.is("ON") {
+		var err error
+		if name, err = pgIdent(p); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("ON"); err != nil {
+		return err
+	}
+	p.accept("ONLY")
+	tableName, err := s.qualifiedName(p)
+	if err != nil {
+		return err
+	}
+	t, err := s.table(tableName)
+	if err != nil {
+		return err
+	}
+	fulltext := false
+	if p.accept("USING") {
+		method, err := pgIdent(p)
+		if err != nil {
+			return err
+		}
+		// gin gist brin ... are not used by the equality lookup of finders
+		fulltext = method != "btree" && method != "hash"
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	var columns []string
+	functional := false
+	for {
+		if p.is("(") {
+			functional = true
+			p.skipUntil(",", ")")
+		} else {
+			c, err := pgIdent(p)
+			if err != nil {
+				return err
+			}
+			if p.is("(") {
+				// function call
+				functional = true
+			}
+			columns = append(columns, c)
+			// COLLATE opclass ASC DESC NULLS FIRST LAST
+			p.skipUntil(",", ")")
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+	// INCLUDE (...) NULLS NOT DISTINCT WITH (...) TABLESPACE x WHERE predicate
+	partial := false
+	for !p.eof() {
+		if p.accept("WHERE") {
+			partial = true
+			break
+		}
+		p.next()
+	}
+	if functional {
+		return nil
+	}
+	// partial unique index is not unique on the whole table
+	return t.addIndex(name, columns, false, unique && !partial, fulltext)
+}
+
+// comment parse COMMENT ON COLUMN [schema.]table.column IS 'comment'
+func (s *pgSchema) comment(p *tokenParser) error {
+	p.accept("COMMENT", "ON", "COLUMN")
+	var names []string
+	for {
+		name, err := pgIdent(p)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		if !p.accept(".") {
+			break
+		}
+	}
+	if len(names) < 2 {
+		return p.errorf("expect table.column")
+	}
+	if err := p.expect("IS"); err != nil {
+		return err
+	}
+	t, err := s.table(names[len(names)-2])
+	if err != nil {
+		return err
+	}
+	c := t.column(names[len(names)-1])
+	if c == nil {
+		return fmt.Errorf("comment column %s.%s is not exist", t.name, names[len(names)-1])
+	}
+	if v := p.next(); v.kind == tokenString {
+		c.ColumnComment = v.text
+	}
+	return nil
+}
+
+// tableComment parse COMMENT ON TABLE [schema.]table IS 'comment'
+func (s *pgSchema) tableComment(p *tokenParser) error {
+	p.accept("COMMENT", "ON", "TABLE")
+	name, err := s.qualifiedName(p)
+	if err != nil {
+		return err
+	}
+	if err := p.expect("IS"); err != nil {
+		return err
+	}
+	t, err := s.table(name)
+	if err != nil {
+		return err
+	}
+	if v := p.next(); v.kind == tokenString {
+		t.comment = v.text
+	}
+	return nil
+}
+
+// alterTable parse ALTER TABLE [ONLY] name ADD [CONSTRAINT x] PRIMARY KEY|UNIQUE|FOREIGN KEY (cols) as pg_dump output
+func (s *pgSchema) alterTable(p *tokenParser) error {
+	p.accept("ALTER", "TABLE")
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	name, err := s.qualifiedName(p)
+	if err != nil {
+		return err
+	}
+	t, ok := s.tables[name]
+	if !ok || !p.accept("ADD") {
+		return nil
+	}
+	var constraint string
+	if p.accept("CONSTRAINT") {
+		if constraint, err = pgIdent(p); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		return t.addKey(p, constraint, true)
+	case p.accept("UNIQUE"):
+		return t.addKey(p, constraint, false)
+	case p.accept("FOREIGN", "KEY"):
+		return s.foreignKey(p, t, constraint)
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"text/template"
 )
@@ -32,3 +34,687 @@ func TestParseStruct(t *testing.T) {
 
 	r.Execute(os.Stdout, m)
 }
+
+func TestMysqlTableCompositePrimaryKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_role.sql")
+	ddl := "CREATE TABLE `user_role` (" +
+		"`user_id` bigint NOT NULL," +
+		"`role_id` int NOT NULL," +
+		"`note` varchar(10) NOT NULL DEFAULT ''," +
+		"PRIMARY KEY (`role_id`,`user_id`)" +
+		") ENGINE=InnoDB"
+	if err := os.WriteFile(path, []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := MysqlTable("", path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.PrimaryKey != nil {
+		t.Fatalf("composite primary key should not set PrimaryKey, got %s", table.PrimaryKey.ColumnName)
+	}
+	if len(table.PrimaryKeys) != 2 || table.PrimaryKeys[0].ColumnName != "role_id" || table.PrimaryKeys[1].ColumnName != "user_id" {
+		t.Fatalf("unexpected primary keys %+v", table.PrimaryKeys)
+	}
+	if got := PKTool(table, "args"); got != "roleId int64, userId int64" {
+		t.Fatalf("PKTool args = %q", got)
+	}
+}
+
+func TestMysqlTableError(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.sql")
+	bad := filepath.Join(dir, "bad.sql")
+	if err := os.WriteFile(empty, []byte("-- no table"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("CREATE TABLE `a` (`id` bigint NOT NULL"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing.sql"), empty, bad} {
+		if _, err := MysqlTable("", path, ""); err == nil {
+			t.Errorf("MysqlTable(%s) = nil, want error", path)
+		}
+		if _, err := PostgresTables("", path, ""); err == nil {
+			t.Errorf("PostgresTables(%s) = nil, want error", path)
+		}
+	}
+}
+
+func TestIndexFinders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account.sql")
+	ddl := "CREATE TABLE `account` (" +
+		"`id` bigint NOT NULL AUTO_INCREMENT," +
+		"`email` varchar(128) NOT NULL," +
+		"`name` varchar(64) NOT NULL," +
+		"`mtime` datetime NOT NULL," +
+		"PRIMARY KEY (`id`)," +
+		"UNIQUE KEY `uk_email` (`email`)," +
+		"KEY `ix_name_mtime` (`name`,`mtime`)," +
+		"KEY `ix_name` (`name`)" +
+		") ENGINE=InnoDB"
+	if err := os.WriteFile(path, []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := MysqlTable("", path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Indexes) != 4 || table.Indexes[0].GoName != "Primary" || table.Indexes[2].GoName != "IxNameMtime" {
+		t.Fatalf("unexpected indexes %+v", table.Indexes)
+	}
+	var got []string
+	for _, f := range table.Finders {
+		got = append(got, fmt.Sprintf("%s:%v:%s", f.GoName, f.Unique, f.Index.Name))
+	}
+	want := "Email:true:uk_email Name:false:ix_name_mtime NameMtime:false:ix_name_mtime"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("finders = %v, want %s", got, want)
+	}
+}
+
+func TestIndexKeysets(t *testing.T) {
+	ddl := "CREATE TABLE `feed` (" +
+		"`user_id` bigint NOT NULL," +
+		"`seq` int NOT NULL," +
+		"`mtime` datetime NOT NULL," +
+		"`title` varchar(64) DEFAULT NULL," +
+		"`body` text NOT NULL COMMENT '@crud:skip'," +
+		"PRIMARY KEY (`user_id`,`seq`)," +
+		"KEY `ix_mtime` (`mtime`)," +
+		"KEY `ix_user_mtime` (`user_id`,`mtime`)," +
+		"KEY `ix_title` (`title`)," +
+		"KEY `ix_body` (`body`(10))," +
+		"KEY `ix_mtime2` (`mtime`)" +
+		")"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	RemoveSkipped(table)
+	keysets := func(ks []*Keyset) string {
+		var got []string
+		for _, k := range ks {
+			got = append(got, fmt.Sprintf("%s:%s:%s", k.GoName, k.Name, ColumnTool(k.Columns, "gocolumn")))
+		}
+		return strings.Join(got, " ")
+	}
+	want := "PK:PRIMARY:UserId, Seq Mtime:ix_mtime:Mtime, UserId, Seq UserIdMtime:ix_user_mtime:UserId, Mtime, Seq"
+	if got := keysets(table.Keysets); got != want {
+		t.Fatalf("keysets = %s, want %s", got, want)
+	}
+	if got, want := keysets(table.OrderKeysets()), "PK:PRIMARY:UserId, Seq Mtime:ix_mtime:Mtime, UserId, Seq"; got != want {
+		t.Fatalf("order keysets = %s, want %s", got, want)
+	}
+	table.PrimaryKeys = nil
+	if err := table.derive(); err != nil || len(table.Keysets) != 0 {
+		t.Fatalf("keysets without primary key = %v %v", table.Keysets, err)
+	}
+}
+
+func TestParseMysqlDump(t *testing.T) {
+	dump := "-- MySQL dump 10.13\n" +
+		"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+		"SET @@SESSION.SQL_LOG_BIN= 0;\n" +
+		"DROP TABLE IF EXISTS `user`;\n" +
+		"CREATE TABLE `user` (\n" +
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT COMMENT 'id; it''s',\n" +
+		"  `name` varchar(100) NOT NULL DEFAULT '',\n" +
+		"  `secret` varchar(100) NOT NULL DEFAULT '' /*!80023 INVISIBLE */,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `uk_name` (`name`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;\n" +
+		"# another table\n" +
+		"DROP TABLE IF EXISTS `user_role`;\n" +
+		"CREATE TABLE `user_role` (\n" +
+		"  `user_id` bigint unsigned NOT NULL,\n" +
+		"  `role_id` int NOT NULL,\n" +
+		"  PRIMARY KEY (`user_id`,`role_id`)\n" +
+		") ENGINE=InnoDB /*!50100 PARTITION BY HASH (`user_id`) PARTITIONS 4 */;\n" +
+		"/*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;\n"
+	tables, err := ParseMysql("test", dump, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].TableName != "user" || tables[1].TableName != "user_role" {
+		t.Fatalf("unexpected tables %+v", tables)
+	}
+	if c := tables[0].Fields[0]; c.ColumnComment != "id; it's" || !c.IsAutoIncrment {
+		t.Fatalf("unexpected column %+v", c)
+	}
+	if !tables[0].Fields[2].IsInvisible {
+		t.Fatal("the column of version comment /*!80023 INVISIBLE */ should be invisible")
+	}
+	if len(tables[1].PrimaryKeys) != 2 {
+		t.Fatalf("unexpected primary keys %+v", tables[1].PrimaryKeys)
+	}
+	if _, err := ParseMysql("test", dump+dump, ""); err == nil {
+		t.Fatal("duplicate table should fail")
+	}
+}
+
+func TestParseMysql8(t *testing.T) {
+	ddl := "CREATE TABLE IF NOT EXISTS `test`.`order` (\n" +
+		"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `uuid` char(36) COLLATE utf8mb4_bin NOT NULL DEFAULT (uuid()),\n" +
+		"  `state` enum('new','paid','it''s') CHARACTER SET utf8mb4 NOT NULL DEFAULT 'new',\n" +
+		"  `price` decimal(10, 2) NOT NULL DEFAULT '0.00',\n" +
+		"  `qty` int NOT NULL DEFAULT -1 CHECK (`qty` <> 0),\n" +
+		"  `total` decimal(12,2) GENERATED ALWAYS AS ((`price` * `qty`)) STORED NOT NULL,\n" +
+		"  `attrs` json DEFAULT (json_object()),\n" +
+		"  `note` varchar(64) AS (json_unquote(json_extract(`attrs`, _utf8mb4'$.note'))) VIRTUAL,\n" +
+		"  `secret` varchar(64) NOT NULL DEFAULT '' INVISIBLE COMMENT 'hidden',\n" +
+		"  `ctime` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),\n" +
+		"  `mtime` timestamp NOT NULL DEFAULT now() ON UPDATE CURRENT_TIMESTAMP(),\n" +
+		"  PRIMARY KEY (`id`) USING BTREE,\n" +
+		"  UNIQUE KEY `uk_uuid` (`uuid`) INVISIBLE,\n" +
+		"  KEY `ix_note` ((lower(`note`))),\n" +
+		"  KEY (`state`, `ctime` DESC),\n" +
+		"  FULLTEXT KEY `ft_secret` (`secret`) WITH PARSER ngram,\n" +
+		"  CONSTRAINT `fk_user` FOREIGN KEY (`id`) REFERENCES `user` (`id`) ON DELETE CASCADE,\n" +
+		"  CONSTRAINT `ck_price` CHECK ((`price` >= 0)) NOT ENFORCED\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci /*!50100 PARTITION BY HASH (`id`) */"
+	tables, err := ParseMysql("test", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	if table.TableName != "order" || table.PrimaryKey == nil || table.PrimaryKey.ColumnType != "bigint unsigned" {
+		t.Fatalf("unexpected table %+v", table)
+	}
+	var got []string
+	for _, c := range table.Fields {
+		got = append(got, fmt.Sprintf("%s:%s:%v:%v:%v:%v", c.ColumnName, c.ColumnType, c.NotNull, c.IsDefaultCurrentTimestamp, c.IsGenerated, c.IsInvisible))
+	}
+	want := []string{
+		"id:bigint unsigned:true:false:false:false",
+		"uuid:char(36):true:false:false:false",
+		"state:enum('new','paid','it''s'):true:false:false:false",
+		"price:decimal(10,2):true:false:false:false",
+		"qty:int:true:false:false:false",
+		"total:decimal(12,2):true:false:true:false",
+		"attrs:json:false:false:false:false",
+		"note:varchar(64):false:false:true:false",
+		"secret:varchar(64):true:false:false:true",
+		"ctime:datetime(3):true:true:false:false",
+		"mtime:timestamp:true:true:false:false",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("columns = %v, want %v", got, want)
+	}
+	if c := table.Fields[5]; !c.IsStored || c.GenerationExpression != "(`price` * `qty`)" {
+		t.Fatalf("generated column %+v", c)
+	}
+	if c := table.Fields[4]; len(c.Checks) != 1 || c.Checks[0].Expression != "`qty` <> 0" {
+		t.Fatalf("column check %+v", c.Checks)
+	}
+	if len(table.Checks) != 1 || table.Checks[0].Name != "ck_price" || !table.Checks[0].NotEnforced {
+		t.Fatalf("table checks %+v", table.Checks)
+	}
+	if table.Fields[8].ColumnComment != "hidden" {
+		t.Fatalf("comment = %q", table.Fields[8].ColumnComment)
+	}
+	got = nil
+	for _, v := range table.Indexes {
+		got = append(got, fmt.Sprintf("%s:%v:%v", v.Name, v.Unique, v.Fulltext))
+	}
+	// the functional index ix_note is skipped, the unnamed index is named by its first column
+	if want := "PRIMARY:true:false uk_uuid:true:false state:false:false ft_secret:false:true"; strings.Join(got, " ") != want {
+		t.Fatalf("indexes = %v, want %s", got, want)
+	}
+	for _, bad := range []string{
+		"CREATE TABLE t (id int, id int)",
+		"CREATE TABLE t (id int PRIMARY KEY, PRIMARY KEY (id))",
+		"CREATE TABLE t (id int, KEY ix (name))",
+		"CREATE TABLE t (id int NOT NULL DEFAULT)",
+		"CREATE TABLE t LIKE s",
+	} {
+		if _, err := ParseMysql("", bad, ""); err == nil {
+			t.Errorf("%s: want error", bad)
+		}
+	}
+}
+
+func TestParsePostgres(t *testing.T) {
+	ddl := `
+CREATE TABLE IF NOT EXISTS public."user" (
+    id bigserial PRIMARY KEY,
+    name character varying(255) NOT NULL DEFAULT ''::character varying,
+    age integer NOT NULL DEFAULT 0 CHECK (age >= 0),
+    tags text[],
+    team_id int REFERENCES team (id) ON DELETE SET NULL,
+    ctime timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT uk_name UNIQUE (name)
+);
+CREATE INDEX ix_age_ctime ON "user" USING btree (age, ctime DESC);
+CREATE INDEX ON "user" (lower(name));
+COMMENT ON COLUMN public."user".name IS 'user''s name';
+
+CREATE TABLE user_role (
+  user_id bigint,
+  role_id integer GENERATED BY DEFAULT AS IDENTITY
+);
+ALTER TABLE ONLY user_role ADD CONSTRAINT user_role_pkey PRIMARY KEY (role_id, user_id);
+`
+	tables, err := ParsePostgres("test", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("tables = %d, want 2", len(tables))
+	}
+	user := tables[0]
+	if user.Dialect != DialectPostgres || user.PrimaryKey == nil || !user.PrimaryKey.IsAutoIncrment || user.PrimaryKey.GoColumnType != "int64" {
+		t.Fatalf("unexpected primary key %+v", user.PrimaryKey)
+	}
+	var got []string
+	for _, c := range user.Fields {
+		got = append(got, fmt.Sprintf("%s:%s:%v:%v", c.ColumnName, c.GoColumnType, c.NotNull, c.IsDefaultCurrentTimestamp))
+	}
+	want := "id:int64:true:false name:string:true:false age:int64:true:false tags:string:false:false team_id:int64:false:false ctime:time.Time:true:true"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("columns = %v, want %s", got, want)
+	}
+	if user.Fields[1].ColumnComment != "user's name" {
+		t.Fatalf("comment = %q", user.Fields[1].ColumnComment)
+	}
+	got = nil
+	for _, f := range user.Finders {
+		got = append(got, fmt.Sprintf("%s:%v:%s", f.GoName, f.Unique, f.Index.Name))
+	}
+	want = "Name:true:uk_name Age:false:ix_age_ctime AgeCtime:false:ix_age_ctime"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("finders = %v, want %s", got, want)
+	}
+	if args := PKTool(tables[1], "args"); args != "roleId int64, userId int64" {
+		t.Fatalf("user_role primary key = %s", args)
+	}
+}
+
+func TestEnumType(t *testing.T) {
+	ddl := "CREATE TABLE `order` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `state` enum('new','in-progress','it''s','已发货','New') NOT NULL DEFAULT 'new',\n" +
+		"  `flags` set('gift','urgent') DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `ix_state` (`state`)\n" +
+		")"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	state, flags := table.Fields[1], table.Fields[2]
+	var got []string
+	for _, v := range state.EnumValues {
+		got = append(got, v.GoName+"="+v.Value)
+	}
+	want := "OrderStateNew=new OrderStateInProgress=in-progress OrderStateItS=it's OrderStateV4=已发货 OrderStateNewV5=New"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("enum values = %v, want %s", got, want)
+	}
+	if state.GoColumnType != "OrderState" || state.ProtoType != "OrderState" || flags.ProtoType != "repeated OrderFlags" {
+		t.Fatalf("enum types = %s %s %s", state.GoColumnType, state.ProtoType, flags.ProtoType)
+	}
+	if args := PackageArgs(table.Finders[0].Columns, "order"); args != "state order.OrderState" {
+		t.Fatalf("finder args = %s", args)
+	}
+	SetNullStyle(table, NullStyleSQL)
+	if flags.GoColumnType != "*OrderFlags" {
+		t.Fatalf("nullable set = %s", flags.GoColumnType)
+	}
+
+	tables, err = ParsePostgres("", "CREATE TYPE mood AS ENUM ('sad', 'ok');\nCREATE TABLE feeling (id bigserial PRIMARY KEY, m mood);", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := tables[0].Fields[1]; m.GoColumnType != "FeelingM" || len(m.EnumValues) != 2 || m.EnumValues[1].GoName != "FeelingMOk" {
+		t.Fatalf("postgres enum = %s %v", m.GoColumnType, m.EnumValues)
+	}
+}
+
+func TestJSONType(t *testing.T) {
+	ddl := "CREATE TABLE `doc` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `settings` json NOT NULL COMMENT 'user settings @type:pkg.Settings',\n" +
+		"  `tags` json DEFAULT NULL COMMENT '@type:[]*github.com/acme/types.Tag',\n" +
+		"  `extra` json DEFAULT NULL COMMENT '@type:map[string]interface{}',\n" +
+		"  `raw` json DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		")"
+	tables, err := ParseMysql("", ddl, "example.com/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	SetNullStyle(table, NullStyleSQL)
+	var got []string
+	for _, c := range table.Fields[1:] {
+		got = append(got, fmt.Sprintf("%s:%v:%s", c.GoColumnType, c.IsJSONType, c.GoImport))
+	}
+	want := "pkg.Settings:true:example.com/app/pkg []*types.Tag:true:github.com/acme/types map[string]interface{}:true: sql.NullString:false:"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("json types = %v, want %s", got, want)
+	}
+	if imports := table.JSONImports(); len(imports) != 2 {
+		t.Fatalf("imports = %v", imports)
+	}
+	if c := table.Fields[1]; c.Comment != "user settings" || table.Fields[2].Comment != "" {
+		t.Fatalf("comments = %q %q", c.Comment, table.Fields[2].Comment)
+	}
+}
+
+func TestDecimalType(t *testing.T) {
+	ddl := "CREATE TABLE `ledger` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `amount` decimal(10, 2) NOT NULL,\n" +
+		"  `plain` numeric NOT NULL,\n" +
+		"  `rate` decimal(20,8) DEFAULT NULL,\n" +
+		"  `ratio` double NOT NULL,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		")"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	SetNullStyle(table, NullStyleSQL)
+	var got []string
+	for _, c := range table.Fields[1:] {
+		got = append(got, fmt.Sprintf("%s:%s:%d,%d", c.GoColumnType, c.ProtoType, c.Precision, c.Scale))
+	}
+	want := "xsql.Decimal:string:10,2 xsql.Decimal:string:10,0 xsql.NullDecimal:string:20,8 float64:double:0,0"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("decimal types = %v, want %s", got, want)
+	}
+	if v := NullValue(table.Fields[3], "a.Rate"); v != "a.Rate.Decimal" {
+		t.Fatalf("NullValue = %s", v)
+	}
+
+	tables, err = ParsePostgres("", "CREATE TABLE wallet (id bigserial PRIMARY KEY, balance numeric(12,2) NOT NULL, rate numeric);", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, r := tables[0].Fields[1], tables[0].Fields[2]; b.GoColumnType != DecimalType || b.Precision != 12 || b.Scale != 2 || r.Precision != 0 {
+		t.Fatalf("postgres numeric = %s %d,%d %d", b.GoColumnType, b.Precision, b.Scale, r.Precision)
+	}
+}
+
+func TestExactInt(t *testing.T) {
+	ddl := "CREATE TABLE `counter` (\n" +
+		"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `flag` tinyint(1) NOT NULL,\n" +
+		"  `small` smallint NOT NULL,\n" +
+		"  `tiny` tinyint unsigned DEFAULT NULL,\n" +
+		"  `n` int DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		")"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	if c := table.Fields[0]; c.GoColumnType != "int64" || c.ProtoType != "int64" {
+		t.Fatalf("default int = %s %s", c.GoColumnType, c.ProtoType)
+	}
+	SetExactInt(table)
+	SetNullStyle(table, NullStyleSQL)
+	var got []string
+	for _, c := range table.Fields {
+		got = append(got, c.GoColumnType+":"+c.ProtoType)
+	}
+	want := "uint64:uint64 bool:bool int16:int32 sql.NullInt32:uint32 sql.NullInt32:int32"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("exact int = %v, want %s", got, want)
+	}
+	if v := GoValue(table.Fields[2], "req.GetSmall()"); v != "int16(req.GetSmall())" {
+		t.Fatalf("GoValue = %s", v)
+	}
+	if v := ProtoValue(table.Fields[3], NullValue(table.Fields[3], "a.Tiny")); v != "uint32(uint8(a.Tiny.Int32))" {
+		t.Fatalf("ProtoValue = %s", v)
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	ddl := "CREATE TABLE `member` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `user_id` bigint NOT NULL COMMENT 'owner @crud:name=UserID @crud:json=userId',\n" +
+		"  `secret` varchar(64) NOT NULL DEFAULT '' COMMENT '@crud:skip',\n" +
+		"  `created` datetime NOT NULL COMMENT '@crud:readonly',\n" +
+		"  `note` text COMMENT '@crud:nowhere',\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `ix_user_secret` (`user_id`, `secret`)\n" +
+		") ENGINE=InnoDB COMMENT='team member @crud:name=Person @crud:track' PARTITION BY HASH (id) (PARTITION p0 COMMENT 'x @crud:skip')"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := tables[0]
+	if table.GoTableName != "Person" || table.PackageName != "person" || table.ProtoName != "person" || table.Skip || !table.Track {
+		t.Fatalf("table = %s %s %s %v %v", table.GoTableName, table.PackageName, table.ProtoName, table.Skip, table.Track)
+	}
+	user, created := table.Fields[1], table.Fields[3]
+	if user.GoColumnName != "UserID" || user.JSONName != "userId" || user.ProtoName != "userID" || !created.IsReadOnly {
+		t.Fatalf("columns = %s %s %s %v", user.GoColumnName, user.JSONName, user.ProtoName, created.IsReadOnly)
+	}
+	// the directives are not in the comment of the generated code
+	if user.Comment != "owner" || created.Comment != "" || user.ColumnComment != "owner @crud:name=UserID @crud:json=userId" {
+		t.Fatalf("comments = %q %q", user.Comment, created.Comment)
+	}
+	var where []string
+	for _, v := range table.GenerateWhereCol {
+		where = append(where, v.ColumnName)
+	}
+	if strings.Join(where, " ") != "id user_id created" {
+		t.Fatalf("where columns = %v", where)
+	}
+	RemoveSkipped(table)
+	var finders []string
+	for _, v := range table.Finders {
+		finders = append(finders, v.GoName)
+	}
+	if len(table.Fields) != 4 || strings.Join(finders, " ") != "UserID" {
+		t.Fatalf("fields = %d finders = %v", len(table.Fields), finders)
+	}
+
+	for _, ddl := range []string{
+		"CREATE TABLE t (id int PRIMARY KEY COMMENT '@crud:skip')",
+		"CREATE TABLE t (id int PRIMARY KEY COMMENT '@crud:name=id')",
+		"CREATE TABLE t (id int PRIMARY KEY, x int COMMENT '@crud:name=Id')",
+		"CREATE TABLE t (id int PRIMARY KEY COMMENT '@crud:unknown')",
+		"CREATE TABLE t (id int) COMMENT '@crud:track'",
+		"CREATE TABLE t (id int PRIMARY KEY, save int) COMMENT '@crud:track'",
+		"CREATE TABLE t (id int PRIMARY KEY, changed_columns int) COMMENT '@crud:track'",
+		"CREATE TABLE t (id int PRIMARY KEY, name int, set_name int COMMENT '@crud:readonly') COMMENT '@crud:track'",
+	} {
+		if _, err := ParseMysql("", ddl, ""); err == nil {
+			t.Errorf("%s: expect error", ddl)
+		}
+	}
+
+	// the renamed and the skipped columns do not conflict with the methods, the readonly column has no setter
+	if _, err := ParseMysql("", "CREATE TABLE t (id int PRIMARY KEY, save int COMMENT '@crud:name=SaveCount', clear_changes int COMMENT '@crud:skip', "+
+		"name int COMMENT '@crud:readonly', set_name int) COMMENT '@crud:track'", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err = ParsePostgres("", "CREATE TABLE t (id bigserial PRIMARY KEY);\nCOMMENT ON TABLE public.t IS '@crud:skip';", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tables[0].Skip {
+		t.Fatalf("postgres table comment %q", tables[0].TableComment)
+	}
+}
+
+func TestBuildEdges(t *testing.T) {
+	ddl := "CREATE TABLE `author` (`id` bigint NOT NULL AUTO_INCREMENT, `parent_id` bigint DEFAULT NULL, PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `author` (`id`));\n" +
+		"CREATE TABLE `post` (`id` bigint NOT NULL AUTO_INCREMENT, `author_id` int NOT NULL, `editor_id` bigint DEFAULT NULL,\n" +
+		"  `category` varchar(8) NOT NULL, PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `fk_author` FOREIGN KEY (`author_id`) REFERENCES `author` (`id`) ON DELETE CASCADE,\n" +
+		"  FOREIGN KEY `ix_editor` (`editor_id`) REFERENCES `test`.`author` (`id`) ON DELETE SET NULL ON UPDATE NO ACTION,\n" +
+		"  FOREIGN KEY (`category`) REFERENCES `category` (`code`),\n" +
+		"  FOREIGN KEY (`id`, `author_id`) REFERENCES `author` (`id`, `parent_id`));\n" +
+		"CREATE TABLE `author_bio` (`id` bigint NOT NULL, `author_id` bigint NOT NULL REFERENCES `post` (`id`), PRIMARY KEY (`id`), UNIQUE KEY `uk_author` (`author_id`),\n" +
+		"  FOREIGN KEY (`author_id`) REFERENCES `author` (`id`));"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fk := tables[1].ForeignKeys[1]; len(tables[1].ForeignKeys) != 4 || fk.Name != "" || fk.RefTable != "author" || fk.Columns[0].ColumnName != "editor_id" {
+		t.Fatalf("foreign keys = %d %+v", len(tables[1].ForeignKeys), fk)
+	}
+	if err := BuildEdges(tables); err != nil {
+		t.Fatal(err)
+	}
+	edges := func(table *Table) string {
+		var res []string
+		for _, e := range table.Edges {
+			res = append(res, fmt.Sprintf("%s:%s:%v:%v:%s", e.Name, e.Ref.TableName, e.Unique, e.BelongsTo, e.KeyType()))
+		}
+		return strings.Join(res, " ")
+	}
+	for i, want := range []string{
+		"Parent:author:true:true:int64 Authors:author:false:false:int64 PostsByAuthor:post:false:false:int64 PostsByEditor:post:false:false:int64 AuthorBio:author_bio:true:false:int64",
+		"Author:author:true:true:int64 Editor:author:true:true:int64",
+		"Author:author:true:true:int64",
+	} {
+		if got := edges(tables[i]); got != want {
+			t.Errorf("%s edges = %s, want %s", tables[i].TableName, got, want)
+		}
+	}
+	SetExactInt(tables[1])
+	SetNullStyle(tables[1], NullStylePtr)
+	if v := KeyValue(tables[1].Fields[1], "int64", "v.AuthorId"); v != "int64(v.AuthorId)" {
+		t.Fatalf("KeyValue = %s", v)
+	}
+	if v := KeyValue(tables[1].Fields[2], "int64", "v.EditorId"); v != "*v.EditorId" {
+		t.Fatalf("KeyValue = %s", v)
+	}
+
+	tables, err = ParsePostgres("", `CREATE TABLE team (id serial PRIMARY KEY, captain_id int UNIQUE);
+CREATE TABLE player (id serial PRIMARY KEY, team_id int CONSTRAINT fk_team REFERENCES team ON DELETE SET NULL, coach_id int,
+  FOREIGN KEY (coach_id) REFERENCES player (id) DEFERRABLE INITIALLY DEFERRED);
+ALTER TABLE ONLY public.team ADD CONSTRAINT fk_captain FOREIGN KEY (captain_id) REFERENCES public.player(id) NOT VALID;`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildEdges(tables); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := edges(tables[0])+" | "+edges(tables[1]), "Captain:player:true:true:int64 Players:player:false:false:int64 | TeamByCaptain:team:true:false:int64 Team:team:true:true:int64 Coach:player:true:true:int64 Players:player:false:false:int64"; got != want {
+		t.Fatalf("postgres edges = %s, want %s", got, want)
+	}
+}
+
+func TestApplyOverride(t *testing.T) {
+	ddl := "CREATE TABLE `member` (`id` bigint NOT NULL AUTO_INCREMENT, `user_id` int NOT NULL, `active` tinyint(1) NOT NULL,\n" +
+		"  `state` enum('on','off') NOT NULL, `settings` text, `secret` varchar(64) NOT NULL, `score` int NOT NULL, PRIMARY KEY (`id`),\n" +
+		"  KEY `ix_user` (`user_id`)) COMMENT '@crud:readonly';"
+	parse := func() *Table {
+		tables, err := ParseMysql("", ddl, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tables[0]
+	}
+	table := parse()
+	err := ApplyOverride(table, map[string]string{"tinyint(1)": "bool", "text": "map[string]interface{}", "int": "int32"}, &TableOverride{
+		Name:    "Person",
+		Package: "people",
+		NoWhere: true,
+		Track:   true,
+		Columns: map[string]*ColumnOverride{
+			"user_id":  {Name: "UserID", JSON: "userId"},
+			"state":    {Type: "string"},
+			"secret":   {Skip: true},
+			"settings": {Type: "*github.com/x/conf.Settings"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.GoTableName != "Person" || table.PackageName != "people" || table.ProtoName != "person" || !table.Track {
+		t.Fatalf("table = %s %s %s %v", table.GoTableName, table.PackageName, table.ProtoName, table.Track)
+	}
+	var got []string
+	for _, v := range table.Fields {
+		got = append(got, fmt.Sprintf("%s:%s:%s:%v:%v:%v", v.GoColumnName, v.GoBaseType, v.JSONName, v.IsJSONType, v.IsSkip, v.IsReadOnly && v.NoWhere))
+	}
+	want := "Id:int64:id:false:false:true UserID:int32:userId:false:false:true Active:bool:active:false:false:true State:string:state:false:false:true " +
+		"Settings:*conf.Settings:settings:true:false:true Secret:string:secret:false:true:true Score:int32:score:false:false:true"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("fields = %s, want %s", strings.Join(got, " "), want)
+	}
+	if len(table.Finders) != 1 || table.Finders[0].GoName != "UserID" || len(table.GenerateWhereCol) != 0 {
+		t.Fatalf("finders = %d where = %d", len(table.Finders), len(table.GenerateWhereCol))
+	}
+	if imports := table.JSONImports(); len(imports) != 1 || imports[0] != "github.com/x/conf" {
+		t.Fatalf("imports = %v", imports)
+	}
+	SetExactInt(table)
+	if table.Fields[1].GoBaseType != "int32" || table.Fields[2].GoBaseType != "bool" || table.Fields[0].GoBaseType != "int64" {
+		t.Fatalf("exactint = %s %s", table.Fields[1].GoBaseType, table.Fields[2].GoBaseType)
+	}
+
+	for _, o := range []*TableOverride{
+		{Columns: map[string]*ColumnOverride{"missing": {Name: "Missing"}}},
+		{Columns: map[string]*ColumnOverride{"score": {Type: "[]string"}}},
+		{Columns: map[string]*ColumnOverride{"score": {Name: "UserId"}}},
+		{Name: "person"},
+		{Track: true, Columns: map[string]*ColumnOverride{"score": {Name: "Save"}}},
+	} {
+		if err := ApplyOverride(parse(), nil, o); err == nil {
+			t.Errorf("ApplyOverride(%+v) = nil, want error", o)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	ddl := "CREATE TABLE `user` (`id` int unsigned NOT NULL AUTO_INCREMENT, `name` varchar(100) NOT NULL COMMENT 'name', `age` int NOT NULL, PRIMARY KEY (`id`));\n" +
+		"CREATE TABLE `orders` (`id` bigint NOT NULL, `user_id` int unsigned NOT NULL, `amount` decimal(10,2) NOT NULL, PRIMARY KEY (`id`));\n" +
+		// mysqldump defines a placeholder view before the real one
+		"CREATE VIEW `user_orders` AS SELECT 1 AS `id`, 1 AS `name`;\n" +
+		"/*!50001 CREATE ALGORITHM=UNDEFINED */ /*!50013 DEFINER=`root`@`localhost` SQL SECURITY DEFINER */\n" +
+		"/*!50001 VIEW `user_orders` AS select `u`.`id` AS `id`,`u`.`name` uname,o.amount,count(`o`.`id`) AS `cnt`," +
+		"cast(u.age as char(10)) AS age from (`user` `u` left join `orders` `o` on((`o`.`user_id` = `u`.`id`))) group by `u`.`id` */;\n" +
+		"CREATE OR REPLACE VIEW adults (uid, uname) AS SELECT u.id, name FROM user AS u WHERE age >= 18 WITH CHECK OPTION;\n" +
+		"CREATE TABLE `totals` (`user_id` int unsigned NOT NULL, `total` decimal(12,2) NOT NULL, PRIMARY KEY (`user_id`));\n" +
+		"CREATE VIEW totals AS SELECT user_id, SUM(amount) AS total FROM orders GROUP BY user_id;\n"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range tables {
+		s := fmt.Sprintf("%s:%v:%d", v.TableName, v.IsView, len(v.PrimaryKeys))
+		for _, c := range v.Fields {
+			s += fmt.Sprintf(" %s:%s:%v", c.ColumnName, c.ColumnType, c.NotNull)
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"user:false:1 id:int unsigned:true name:varchar(100):true age:int:true",
+		"orders:false:1 id:bigint:true user_id:int unsigned:true amount:decimal(10,2):true",
+		"user_orders:true:0 id:int unsigned:true uname:varchar(100):true amount:decimal(10,2):false cnt:bigint:true age:varchar(10):false",
+		"adults:true:0 uid:int unsigned:true uname:varchar(100):true",
+		"totals:true:1 user_id:int unsigned:true total:decimal(12,2):true",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("tables\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, v := range []string{
+		"CREATE TABLE t (id int NOT NULL); CREATE VIEW v AS SELECT id + 1 AS n FROM t",
+		"CREATE TABLE t (id int NOT NULL); CREATE VIEW v AS SELECT x FROM t",
+		"CREATE TABLE t (id int NOT NULL); CREATE VIEW v (a, b) AS SELECT id FROM t",
+		"CREATE TABLE t (id int NOT NULL); CREATE VIEW v AS SELECT * FROM (SELECT id FROM t) d",
+		"CREATE TABLE t (id int NOT NULL); CREATE VIEW v AS SELECT COUNT(*) FROM t",
+	} {
+		if _, err := ParseMysql("", v, ""); err == nil {
+			t.Errorf("ParseMysql(%s) = nil, want error", v)
+		}
+	}
+}
@@ -1,10 +1,11 @@
 package model
 
 import (
+	"go/token"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"golang.org/x/mod/modfile"
@@ -63,7 +64,10 @@ func MysqlToGoFieldType(dt, ct string) (string, int) {
 	case "float":
 		typ = "float32"
 		gtp = bigtypeCompare
-	case "decimal", "double":
+	case "decimal":
+		typ = DecimalType
+		gtp = bigtypeCompare
+	case "double":
 		typ = "float64"
 		gtp = bigtypeCompare
 	case "binary", "varbinary":
@@ -82,6 +86,267 @@ func MysqlToGoFieldType(dt, ct string) (string, int) {
 	}
 	return typ, gtp
 }
+
+// DecimalType go type of the decimal column, the exact decimal of xsql
+const DecimalType = "xsql.Decimal"
+
+// sql dialect of the DDL and generated code
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+)
+
+// nullable column go type style
+const (
+	NullStyleNone = ""    // nullable column use the same go type as not null column
+	NullStyleSQL  = "sql" // sql.NullString sql.NullInt64 ...
+	NullStylePtr  = "ptr" // *string *int64 ...
+)
+
+// GoNullFieldType returns the go type of a nullable column according to the style
+func GoNullFieldType(typ, style string) string {
+	switch style {
+	case NullStyleSQL:
+		switch typ {
+		case "string":
+			return "sql.NullString"
+		case "bool":
+			return "sql.NullBool"
+		case "float32", "float64":
+			return "sql.NullFloat64"
+		case "int8", "int16", "int32", "uint8", "uint16":
+			return "sql.NullInt32"
+		case "int", "int64", "uint32":
+			return "sql.NullInt64"
+		case "uint64":
+			// sql.NullInt64 overflows above math.MaxInt64
+			return "*uint64"
+		case "time.Time":
+			return "sql.NullTime"
+		case DecimalType:
+			return "xsql.NullDecimal"
+		}
+	case NullStylePtr:
+		if typ != "[]byte" {
+			return "*" + typ
+		}
+	}
+	return typ
+}
+
+// sqlNullValueField sql.Null* type value field name and type
+var sqlNullValueField = map[string][2]string{
+	"sql.NullString":   {"String", "string"},
+	"sql.NullBool":     {"Bool", "bool"},
+	"sql.NullFloat64":  {"Float64", "float64"},
+	"sql.NullInt32":    {"Int32", "int32"},
+	"sql.NullInt64":    {"Int64", "int64"},
+	"sql.NullTime":     {"Time", "time.Time"},
+	"xsql.NullDecimal": {"Decimal", DecimalType},
+}
+
+// IsNullType reports whether the column go type is sql.Null* or pointer type
+func IsNullType(c *Column) bool {
+	return c.GoColumnType != c.GoBaseType
+}
+
+// NullCheck returns go expression reports whether the nullable field expr is not null
+func NullCheck(c *Column, expr string) string {
+	if strings.HasPrefix(c.GoColumnType, "*") {
+		return expr + " != nil"
+	}
+	return expr + ".Valid"
+}
+
+// NullValue returns go expression of the not null value of the nullable field expr
+func NullValue(c *Column, expr string) string {
+	if strings.HasPrefix(c.GoColumnType, "*") {
+		return "*" + expr
+	}
+	f := sqlNullValueField[c.GoColumnType]
+	if f[1] != c.GoBaseType {
+		return c.GoBaseType + "(" + expr + "." + f[0] + ")"
+	}
+	return expr + "." + f[0]
+}
+
+// NullAssign returns go statement which assign the not null value src to the nullable field dst
+func NullAssign(c *Column, dst, src string) string {
+	if strings.HasPrefix(c.GoColumnType, "*") {
+		if token.IsIdentifier(src) {
+			return dst + " = &" + src
+		}
+		nv := GoParamName(c.GoColumnName) + "Val"
+		return nv + " := " + src + "\n" + dst + " = &" + nv
+	}
+	f := sqlNullValueField[c.GoColumnType]
+	if f[1] != c.GoBaseType {
+		src = f[1] + "(" + src + ")"
+	}
+	return dst + " = " + c.GoColumnType + "{" + f[0] + ": " + src + ", Valid: true}"
+}
+
+// DatabaseDefault reports whether the database computes the value of the column which is left out of the INSERT:
+// DEFAULT CURRENT_TIMESTAMP, ON UPDATE CURRENT_TIMESTAMP, the expression default (uuid()) and the default of the time column.
+// The auto increment, generated and typed JSON columns are not
+func DatabaseDefault(c *Column) bool {
+	if c.IsAutoIncrment || c.IsGenerated || c.IsJSONType {
+		return false
+	}
+	if c.IsDefaultCurrentTimestamp || c.IsOnUpdateCurrentTimestamp {
+		return true
+	}
+	if c.ColumnDefault == "" {
+		return false
+	}
+	_, literal := defaultLiteral(c.ColumnDefault)
+	return !literal || c.GoBaseType == "time.Time"
+}
+
+// NotZero returns go expression reports whether the field expr has a value, it is not null and not the zero value
+func NotZero(c *Column, expr string) string {
+	switch {
+	case IsNullType(c):
+		return NullCheck(c, expr)
+	case c.GoBaseType == "time.Time" || c.GoBaseType == DecimalType:
+		return "!" + expr + ".IsZero()"
+	case c.GoBaseType == "bool":
+		return expr
+	case c.GoBaseType == "[]byte":
+		return "len(" + expr + ") > 0"
+	case c.GoBaseType == "string" || c.GoEnumType != "" && c.DataType == "enum":
+		return expr + ` != ""`
+	}
+	return expr + " != 0"
+}
+
+// Differs returns go expression reports whether the values a and b of the column are different,
+// it is empty if the go type can not be compared, such as []byte and the json types
+func Differs(c *Column, a, b string) string {
+	switch {
+	case c.IsJSONType || c.GoColumnType == "[]byte" || strings.HasPrefix(c.GoColumnType, "*"),
+		c.GoColumnType == "sql.NullTime" || c.GoColumnType == "xsql.NullDecimal":
+		return ""
+	case c.GoColumnType == "time.Time" || c.GoColumnType == DecimalType:
+		return "!" + a + ".Equal(" + b + ")"
+	}
+	return a + " != " + b
+}
+
+// GoDefault returns the go expression of the literal DEFAULT of the column in its not null go type, New<Table>() assigns it.
+// It is empty if the column has no literal default, the default is computed by the database or it is the zero value of a not null field
+func GoDefault(c *Column) string {
+	if c.ColumnDefault == "" || c.IsGenerated || c.IsAutoIncrment || c.IsJSONType || DatabaseDefault(c) {
+		return ""
+	}
+	value, _ := defaultLiteral(c.ColumnDefault)
+	var expr string
+	zero := value == ""
+	switch {
+	case c.GoEnumType != "" && c.DataType == "set":
+		var names []string
+		for _, v := range strings.Split(value, ",") {
+			for _, e := range c.EnumValues {
+				if e.Value == v {
+					names = append(names, e.GoName)
+				}
+			}
+		}
+		expr = strings.Join(names, " | ")
+	case c.GoEnumType != "":
+		for _, e := range c.EnumValues {
+			if e.Value == value {
+				expr = e.GoName
+			}
+		}
+	case c.GoBaseType == "bool":
+		v := strings.ToLower(value)
+		expr = strconv.FormatBool(v == "1" || v == "true" || v == "b'1'")
+		zero = expr == "false"
+	case c.GoBaseType == "string":
+		expr = strconv.Quote(value)
+	case IsNumber(c.GoBaseType):
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || strings.Contains(c.GoBaseType, "int") && f != float64(int64(f)) {
+			return ""
+		}
+		zero = f == 0
+		expr = value
+		if c.GoBaseType == DecimalType {
+			expr = "xsql.MustDecimal(" + strconv.Quote(value) + ")"
+		}
+	}
+	if zero && !IsNullType(c) {
+		return ""
+	}
+	return expr
+}
+
+// defaultLiteral returns the value of the literal default 'a' 1.5 TRUE b'1', the postgres cast 'a'::text is removed.
+// It reports false for the expression default
+func defaultLiteral(def string) (string, bool) {
+	if i := strings.LastIndex(def, "::"); i > 0 && !strings.Contains(def[i:], "'") {
+		def = def[:i]
+	}
+	if len(def) >= 2 && def[0] == '\'' && def[len(def)-1] == '\'' {
+		return strings.ReplaceAll(def[1:len(def)-1], "''", "'"), true
+	}
+	switch lower := strings.ToLower(def); {
+	case lower == "true" || lower == "false":
+		return lower, true
+	case strings.HasPrefix(lower, "b'") || strings.HasPrefix(lower, "x'"):
+		return lower, true
+	}
+	if _, err := strconv.ParseFloat(def, 64); err == nil {
+		return def, true
+	}
+	return def, false
+}
+
+// protoWrapper proto type to google.protobuf wrapper message and go constructor
+var protoWrapper = map[string][2]string{
+	"string": {"StringValue", "String"},
+	"bool":   {"BoolValue", "Bool"},
+	"bytes":  {"BytesValue", "Bytes"},
+	"float":  {"FloatValue", "Float"},
+	"double": {"DoubleValue", "Double"},
+	"int32":  {"Int32Value", "Int32"},
+	"int64":  {"Int64Value", "Int64"},
+	"uint32": {"UInt32Value", "UInt32"},
+	"uint64": {"UInt64Value", "UInt64"},
+}
+
+// protoGoType go type of the proto scalar type
+var protoGoType = map[string]string{
+	"float": "float32", "double": "float64", "int32": "int32", "uint32": "uint32", "int64": "int64", "uint64": "uint64",
+}
+
+// GoValue converts the go expression of the proto field to the go type of the column, int32 => int8(expr)
+func GoValue(c *Column, expr string) string {
+	if p, ok := protoGoType[c.ProtoType]; ok && IsNumber(c.GoBaseType) && p != c.GoBaseType {
+		return c.GoBaseType + "(" + expr + ")"
+	}
+	return expr
+}
+
+// ProtoValue converts the go expression of the not null column value to the go type of the proto field, int8 => int32(expr)
+func ProtoValue(c *Column, expr string) string {
+	if p, ok := protoGoType[c.ProtoType]; ok && IsNumber(c.GoBaseType) && p != c.GoBaseType {
+		return p + "(" + expr + ")"
+	}
+	return expr
+}
+
+// ProtoWrapperType returns google.protobuf wrapper message of the proto type. string => google.protobuf.StringValue
+func ProtoWrapperType(p string) string {
+	return "google.protobuf." + protoWrapper[p][0]
+}
+
+// ProtoWrapperFunc returns the wrapperspb constructor of the proto type. string => wrapperspb.String
+func ProtoWrapperFunc(p string) string {
+	return "wrapperspb." + protoWrapper[p][1]
+}
+
 func GoTypeToProtoType(g string) string {
 	switch g {
 	case "[]byte":
@@ -102,7 +367,7 @@ func GoTypeToProtoType(g string) string {
 		return "int64"
 	case "uint64":
 		return "uint64"
-	case "time.Time":
+	case "time.Time", DecimalType:
 		return "string"
 	default:
 		return ""
@@ -127,7 +392,7 @@ func GoTypeToTypeScriptDefaultValue(g string) string {
 		return "0"
 	case "uint64", "int64", "int":
 		return "0n"
-	case "time.Time":
+	case "time.Time", DecimalType:
 		return "''"
 	default:
 		return ""
@@ -165,22 +430,87 @@ func SQLTool(t *Table, flag string) string {
 	return strings.Join(ns, ",")
 }
 
+// PKTool PKTool like SQLTool but only for primary key columns
+func PKTool(t *Table, flag string) string {
+	if flag == "reqtableget" {
+		var ns []string
+		for _, v := range t.PrimaryKeys {
+			ns = append(ns, GoValue(v, "req.Get"+t.GoTableName+"().Get"+v.GoColumnName+"()"))
+		}
+		return strings.Join(ns, ", ")
+	}
+	return ColumnTool(t.PrimaryKeys, flag)
+}
+
+// ColumnTool ColumnTool like SQLTool for the given columns
+func ColumnTool(columns []*Column, flag string) string {
+	var ns []string
+	for _, v := range columns {
+		switch flag {
+		case "args":
+			ns = append(ns, GoParamName(v.GoColumnName)+" "+v.GoBaseType)
+		case "params":
+			ns = append(ns, GoParamName(v.GoColumnName))
+		case "gofield":
+			ns = append(ns, "a."+v.GoColumnName)
+		case "gocolumn":
+			ns = append(ns, v.GoColumnName)
+		case "reqget":
+			ns = append(ns, GoValue(v, "req.Get"+v.GoColumnName+"()"))
+		case "field":
+			ns = append(ns, "`"+v.ColumnName+"`")
+		default:
+			ns = append(ns, flag)
+		}
+	}
+	return strings.Join(ns, ", ")
+}
+
+// PackageArgs like ColumnTool args but the enum and set types are qualified by the package of the table
+func PackageArgs(columns []*Column, pkg string) string {
+	var ns []string
+	for _, v := range columns {
+		typ := v.GoBaseType
+		if v.GoEnumType != "" {
+			typ = pkg + "." + typ
+		}
+		ns = append(ns, GoParamName(v.GoColumnName)+" "+typ)
+	}
+	return strings.Join(ns, ", ")
+}
+
+// reservedParamNames are identifiers used by the templates that a parameter must not shadow
+var reservedParamNames = map[string]bool{
+	"a": true, "s": true, "u": true, "d": true, "in": true, "v": true,
+	"ctx": true, "eq": true, "err": true,
+}
+
+// GoParamName converts a go field name to a go parameter name. UserId => userId, ID => id
+func GoParamName(s string) string {
+	b := []byte(s)
+	for i := 0; i < len(b) && isASCIIUpper(b[i]); i++ {
+		if i > 0 && i+1 < len(b) && isASCIILower(b[i+1]) {
+			break
+		}
+		b[i] += 'a' - 'A'
+	}
+	name := string(b)
+	if token.IsKeyword(name) || reservedParamNames[name] {
+		name += "Arg"
+	}
+	return name
+}
+
 func IsNumber(arg string) bool {
 	switch arg {
 	case "int8", "int16", "int", "int32", "int64",
 		"uint", "uint8", "uint16", "uint32", "uint64",
-		"float32", "float64":
+		"float32", "float64", DecimalType:
 		return true
 	}
 	return false
 }
 
-func trimTimeStampFunc(raw string) string {
-	s := strings.ReplaceAll(raw, "current_timestamp()", "current_timestamp")
-	s = strings.ReplaceAll(s, "CURRENT_TIMESTAMP()", "CURRENT_TIMESTAMP")
-	return s
-}
-
 func GoCamelCase(s string) string {
 	// Invariant: if the next letter is lower case, it must be converted
 	// to upper case.
@@ -252,6 +582,11 @@ func JSONSnakeCase(s string) string {
 	return string(b)
 }
 
+// SnakeCase converts a go identifier to a snake_case identifier, UserRole => user_role
+func SnakeCase(s string) string {
+	return JSONSnakeCase(lowerFirst(s))
+}
+
 func isASCIILower(c byte) bool {
 	return 'a' <= c && c <= 'z'
 }
@@ -267,25 +602,30 @@ func GetCurrentPath() string {
 	return exPath
 }
 
-func GetRelativePath() string {
-	modName, rootPath := GetModuleName()
+// GetRelativePath returns the import path of the working directory in the module of the nearest go.mod
+func GetRelativePath() (string, error) {
+	modName, rootPath, err := GetModuleName()
+	if err != nil {
+		return "", err
+	}
 	pwd := GetCurrentPath()
 	relative := strings.TrimPrefix(pwd, rootPath)
-	return filepath.Join(modName, relative)
+	return filepath.Join(modName, relative), nil
 }
 
-func GetModuleName() (string, string) {
+// GetModuleName returns the module name and the root path of the nearest go.mod, empty without go.mod
+func GetModuleName() (string, string, error) {
 	mod := GoModFilePath()
 	if mod == "" {
-		return "", ""
+		return "", "", nil
 	}
 	f, err := ioutil.ReadFile(mod)
 	if err != nil {
-		log.Fatal(err)
+		return "", "", err
 	}
 	p, _ := filepath.Split(mod)
 	// module name and project root path
-	return modfile.ModulePath(f), filepath.Clean(p)
+	return modfile.ModulePath(f), filepath.Clean(p), nil
 }
 
 func GoModFilePath() string {
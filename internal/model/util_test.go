@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -11,3 +12,126 @@ func TestGoModFilePath(t *testing.T) {
 	fmt.Println(got)
 
 }
+
+func TestGoParamName(t *testing.T) {
+	for in, want := range map[string]string{
+		"Id":      "id",
+		"UserId":  "userId",
+		"ID":      "id",
+		"URLPath": "urlPath",
+		"Type":    "typeArg",
+		"Ctx":     "ctxArg",
+	} {
+		if got := GoParamName(in); got != want {
+			t.Errorf("GoParamName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNullAssign(t *testing.T) {
+	c := &Column{GoColumnName: "Score", GoBaseType: "float32"}
+	c.GoColumnType = GoNullFieldType(c.GoBaseType, NullStyleSQL)
+	if got, want := NullAssign(c, "a.Score", "v"), "a.Score = sql.NullFloat64{Float64: float64(v), Valid: true}"; got != want {
+		t.Errorf("NullAssign sql = %q, want %q", got, want)
+	}
+	if got, want := NullValue(c, "a.Score"), "float32(a.Score.Float64)"; got != want {
+		t.Errorf("NullValue sql = %q, want %q", got, want)
+	}
+	c.GoColumnType = GoNullFieldType(c.GoBaseType, NullStylePtr)
+	if got, want := NullAssign(c, "a.Score", "req.GetScore()"), "scoreVal := req.GetScore()\na.Score = &scoreVal"; got != want {
+		t.Errorf("NullAssign ptr = %q, want %q", got, want)
+	}
+	if got, want := NullCheck(c, "a.Score"), "a.Score != nil"; got != want {
+		t.Errorf("NullCheck ptr = %q, want %q", got, want)
+	}
+}
+
+func TestGoNullFieldType(t *testing.T) {
+	for _, c := range []struct {
+		typ, style, want string
+	}{
+		{"string", NullStyleSQL, "sql.NullString"},
+		{"uint16", NullStyleSQL, "sql.NullInt32"},
+		{"uint32", NullStyleSQL, "sql.NullInt64"},
+		{"uint64", NullStyleSQL, "*uint64"},
+		{"uint64", NullStylePtr, "*uint64"},
+		{"uint64", NullStyleNone, "uint64"},
+		{DecimalType, NullStyleSQL, "xsql.NullDecimal"},
+		{"[]byte", NullStyleSQL, "[]byte"},
+		{"[]byte", NullStylePtr, "[]byte"},
+	} {
+		if got := GoNullFieldType(c.typ, c.style); got != c.want {
+			t.Errorf("GoNullFieldType(%s, %q) = %s, want %s", c.typ, c.style, got, c.want)
+		}
+	}
+
+	// the nullable bigint unsigned of -nullable sql -exactint keeps the values above math.MaxInt64
+	tables, err := ParseMysql("", "CREATE TABLE `t` (`id` bigint unsigned NOT NULL, `n` bigint unsigned DEFAULT NULL, `m` int unsigned DEFAULT NULL, PRIMARY KEY (`id`));", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetExactInt(tables[0])
+	SetNullStyle(tables[0], NullStyleSQL)
+	n, m := tables[0].Fields[1], tables[0].Fields[2]
+	if n.GoColumnType != "*uint64" || m.GoColumnType != "sql.NullInt64" {
+		t.Fatalf("go types = %s %s", n.GoColumnType, m.GoColumnType)
+	}
+	if got := NullAssign(n, "a.N", "v"); got != "a.N = &v" {
+		t.Errorf("NullAssign = %q", got)
+	}
+	if got := NullValue(n, "a.N"); got != "*a.N" {
+		t.Errorf("NullValue = %q", got)
+	}
+}
+
+func TestColumnDefaults(t *testing.T) {
+	ddl := "CREATE TABLE `t` (`id` bigint NOT NULL AUTO_INCREMENT, `uuid` char(36) NOT NULL DEFAULT (uuid()), " +
+		"`title` varchar(64) NOT NULL DEFAULT 'it''s', `empty` varchar(64) NOT NULL DEFAULT '', `level` int NOT NULL DEFAULT '3', " +
+		"`price` decimal(10,2) NOT NULL DEFAULT '9.90', `ok` tinyint(1) NOT NULL DEFAULT 1, `state` enum('draft','live') NOT NULL DEFAULT 'live', " +
+		"`tags` set('a','b','c') NOT NULL DEFAULT 'a,c', `ctime` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+		"`mtime` datetime NOT NULL DEFAULT '2000-01-01 00:00:00' ON UPDATE CURRENT_TIMESTAMP, " +
+		"`total` decimal(12,2) GENERATED ALWAYS AS (`price` * `level`) STORED, PRIMARY KEY (`id`));"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetExactInt(tables[0])
+	var got []string
+	for _, c := range tables[0].Fields {
+		got = append(got, fmt.Sprintf("%s:%v:%v:%s:%s", c.ColumnName, DatabaseDefault(c), c.IsReadOnly, GoDefault(c), NotZero(c, "a."+c.GoColumnName)))
+	}
+	want := []string{
+		"id:false:false::a.Id != 0",
+		"uuid:true:false::a.Uuid != \"\"",
+		"title:false:false:\"it's\":a.Title != \"\"",
+		"empty:false:false::a.Empty != \"\"",
+		"level:false:false:3:a.Level != 0",
+		"price:false:false:xsql.MustDecimal(\"9.90\"):!a.Price.IsZero()",
+		"ok:false:false:true:a.Ok",
+		"state:false:false:TStateLive:a.State != \"\"",
+		"tags:false:false:TTagsA | TTagsC:a.Tags != 0",
+		"ctime:true:false::!a.Ctime.IsZero()",
+		"mtime:true:false::!a.Mtime.IsZero()",
+		"total:false:true::!a.Total.IsZero()",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("columns\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiffers(t *testing.T) {
+	ddl := "CREATE TABLE `t` (`id` bigint NOT NULL, `name` varchar(64) NOT NULL, `price` decimal(10,2) NOT NULL, " +
+		"`ctime` datetime NOT NULL, `note` varchar(64) DEFAULT NULL, `data` blob NOT NULL, `conf` json NOT NULL, PRIMARY KEY (`id`));"
+	tables, err := ParseMysql("", ddl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range tables[0].Fields {
+		got = append(got, c.ColumnName+":"+Differs(c, "a", "v"))
+	}
+	want := "id:a != v name:a != v price:!a.Equal(v) ctime:!a.Equal(v) note:a != v data: conf:a != v"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("differs = %s, want %s", strings.Join(got, " "), want)
+	}
+}
@@ -0,0 +1,403 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// myView view defined by the mysql CREATE VIEW statement
+type myView struct {
+	name    string
+	columns []string   // column list of the view, the names of the select items are used when it is empty
+	body    []sqlToken // the select statement
+}
+
+// viewFrom table of the FROM clause of the view
+type viewFrom struct {
+	table    *myTable // nil for the derived table and the table which is not defined in the .sql files
+	alias    string   // alias of the table, the table name if it has no alias
+	nullable bool     // the table is outer joined, its columns are nullable in the view
+}
+
+// viewItem item of the select list of the view
+type viewItem struct {
+	name    string // alias of the item, the column name of the column reference
+	table   string // qualifier of the column reference t.col t.*
+	column  string // column name of the column reference, * for all columns, empty for the expression
+	typ     *Column
+	unknown string // text of the expression whose type can not be inferred
+}
+
+// viewKeywords keywords which end the table reference of the FROM clause, they are not aliases
+var viewKeywords = map[string]bool{
+	"ON": true, "USING": true, "JOIN": true, "INNER": true, "CROSS": true, "LEFT": true, "RIGHT": true, "NATURAL": true,
+	"STRAIGHT_JOIN": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "UNION": true,
+	"WINDOW": true, "FOR": true, "LOCK": true, "INTO": true, "WITH": true, "USE": true, "FORCE": true, "IGNORE": true,
+	"PARTITION": true, "FROM": true,
+}
+
+// mysqlViewHead consume CREATE [OR REPLACE] [ALGORITHM = x] [DEFINER = user] [SQL SECURITY x] VIEW,
+// nothing is consumed if the statement is not CREATE VIEW
+func mysqlViewHead(p *tokenParser) bool {
+	start := p.pos
+	if !p.accept("CREATE") {
+		return false
+	}
+	p.accept("OR", "REPLACE")
+	for {
+		switch {
+		case p.accept("ALGORITHM"):
+			p.accept("=")
+			p.next()
+		case p.accept("DEFINER"):
+			for !p.eof() && !p.is("SQL") && !p.is("VIEW") {
+				p.next()
+			}
+		case p.accept("SQL", "SECURITY"):
+			p.next()
+		case p.accept("VIEW"):
+			return true
+		default:
+			p.pos = start
+			return false
+		}
+	}
+}
+
+// mysqlCreateView parse CREATE VIEW statement after the VIEW keyword
+func mysqlCreateView(p *tokenParser) (*myView, error) {
+	name, err := mysqlName(p)
+	if err != nil {
+		return nil, err
+	}
+	v := &myView{name: name}
+	if p.is("(") {
+		if v.columns, err = p.identList((*tokenParser).ident); err != nil {
+			return nil, fmt.Errorf("view %s: %v", name, err)
+		}
+	}
+	if err := p.expect("AS"); err != nil {
+		return nil, fmt.Errorf("view %s: %v", name, err)
+	}
+	v.body = p.toks[p.pos:]
+	return v, nil
+}
+
+// infer the columns of the view from its select statement. The selected columns have the types of the columns
+// of the tables, outer joined tables make them nullable, CAST(x AS type) and COUNT(x) have their result types.
+// The other expressions need a CREATE TABLE statement of the same name as the view which declares the columns
+func (v *myView) infer(tables map[string]*myTable) ([]*Column, error) {
+	p := &tokenParser{toks: v.body}
+	for p.accept("(") {
+	}
+	if err := p.expect("SELECT"); err != nil {
+		return nil, fmt.Errorf("view %s: %v", v.name, err)
+	}
+	for p.accept("ALL") || p.accept("DISTINCT") || p.accept("DISTINCTROW") || p.accept("HIGH_PRIORITY") ||
+		p.accept("STRAIGHT_JOIN") || p.accept("SQL_CALC_FOUND_ROWS") || p.accept("SQL_NO_CACHE") {
+	}
+	var items []*viewItem
+	for {
+		items = append(items, newViewItem(viewExpr(p)))
+		if !p.accept(",") {
+			break
+		}
+	}
+	var from []*viewFrom
+	if p.accept("FROM") {
+		from = viewFromClause(p, tables)
+	}
+	var columns []*Column
+	for _, item := range items {
+		cols, err := item.resolve(from)
+		if err != nil {
+			return nil, fmt.Errorf("view %s: %v, declare the columns by CREATE TABLE %s before the view", v.name, err, v.name)
+		}
+		columns = append(columns, cols...)
+	}
+	if len(v.columns) > 0 {
+		if len(v.columns) != len(columns) {
+			return nil, fmt.Errorf("view %s: the column list has %d columns but the select has %d", v.name, len(v.columns), len(columns))
+		}
+		for i, c := range columns {
+			c.ColumnName = v.columns[i]
+		}
+	}
+	for i, c := range columns {
+		if c.ColumnName == "" {
+			return nil, fmt.Errorf("view %s: column %d has no name, add an alias to it", v.name, i+1)
+		}
+		c.OrdinalPosition = i
+	}
+	return columns, nil
+}
+
+// viewExpr consume a select item until , FROM or the end of the select list at depth 0
+func viewExpr(p *tokenParser) []sqlToken {
+	start, depth := p.pos, 0
+	for !p.eof() {
+		t := p.peek()
+		if depth == 0 && (p.is(",") || p.is(")") || p.is("FROM") || p.is("INTO") || p.is("UNION")) {
+			break
+		}
+		if t.kind == tokenPunct && t.text == "(" {
+			depth++
+		}
+		if t.kind == tokenPunct && t.text == ")" {
+			depth--
+		}
+		p.pos++
+	}
+	return p.toks[start:p.pos]
+}
+
+// newViewItem parse the select item: * t.* [db.][t.]col COUNT(x) CAST(x AS type) CONVERT(x, type) with the optional alias
+func newViewItem(expr []sqlToken) *viewItem {
+	item := &viewItem{}
+	n := len(expr)
+	switch {
+	case n >= 3 && isKeyword(expr[n-2], "AS") && isName(expr[n-1]):
+		item.name, expr = expr[n-1].text, expr[:n-2]
+	case n >= 2 && isName(expr[n-1]) && isAliasAfter(expr[n-2]) &&
+		!(expr[n-1].kind == tokenIdent && contains([]string{"END", "NULL", "TRUE", "FALSE", "UNKNOWN"}, strings.ToUpper(expr[n-1].text))):
+		item.name, expr = expr[n-1].text, expr[:n-1]
+	}
+	n = len(expr)
+	switch {
+	case n == 1 && isPunct(expr[0], "*"):
+		item.column = "*"
+	case n == 3 && isName(expr[0]) && isPunct(expr[1], ".") && isPunct(expr[2], "*"):
+		item.table, item.column = expr[0].text, "*"
+	case isColumnRef(expr):
+		item.column = expr[n-1].text
+		if n >= 3 {
+			item.table = expr[n-3].text
+		}
+		if item.name == "" {
+			item.name = item.column
+		}
+	case n >= 3 && isKeyword(expr[0], "COUNT") && isPunct(expr[1], "(") && isPunct(expr[n-1], ")"):
+		item.typ = &Column{DataType: "bigint", ColumnType: "bigint", NotNull: true}
+	case n >= 3 && (isKeyword(expr[0], "CAST") || isKeyword(expr[0], "CONVERT")) && isPunct(expr[1], "(") && isPunct(expr[n-1], ")"):
+		sep := "AS"
+		if isKeyword(expr[0], "CONVERT") {
+			sep = ","
+		}
+		if i := lastAtDepth0(expr[2:n-1], sep); i >= 0 {
+			if dt, ct, ok := castType(expr[2+i+1 : n-1]); ok {
+				item.typ = &Column{DataType: dt, ColumnType: ct}
+			}
+		}
+	}
+	if item.column == "" && item.typ == nil {
+		item.unknown = joinTokens(expr)
+	}
+	return item
+}
+
+// resolve the columns of the select item, the column types are taken from the tables of the FROM clause
+func (item *viewItem) resolve(from []*viewFrom) ([]*Column, error) {
+	if item.unknown != "" {
+		return nil, fmt.Errorf("can not infer the type of %s", item.unknown)
+	}
+	if item.typ != nil {
+		item.typ.ColumnName = item.name
+		return []*Column{item.typ}, nil
+	}
+	var tables []*viewFrom
+	for _, f := range from {
+		if item.table == "" || strings.EqualFold(f.alias, item.table) {
+			tables = append(tables, f)
+		}
+	}
+	if item.column == "*" {
+		if len(tables) == 0 {
+			return nil, fmt.Errorf("unknown table %s", item.table)
+		}
+		var res []*Column
+		for _, f := range tables {
+			if f.table == nil {
+				return nil, fmt.Errorf("can not infer the columns of %s.*", f.alias)
+			}
+			for _, c := range f.table.columns {
+				// invisible columns are not selected by *
+				if !c.IsInvisible {
+					res = append(res, viewColumn(c.ColumnName, c, f.nullable))
+				}
+			}
+		}
+		return res, nil
+	}
+	var found *Column
+	var nullable bool
+	for _, f := range tables {
+		if f.table == nil {
+			continue
+		}
+		if c := f.table.column(item.column); c != nil {
+			if found != nil {
+				return nil, fmt.Errorf("column %s is ambiguous", item.column)
+			}
+			found, nullable = c, f.nullable
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("can not infer the type of column %s", item.column)
+	}
+	return []*Column{viewColumn(item.name, found, nullable)}, nil
+}
+
+// viewColumn copy the definition of the table column to the view column, the comment carries the annotations
+func viewColumn(name string, c *Column, nullable bool) *Column {
+	return &Column{
+		ColumnName:    name,
+		DataType:      c.DataType,
+		ColumnType:    c.ColumnType,
+		ColumnComment: c.ColumnComment,
+		NotNull:       c.NotNull && !nullable,
+	}
+}
+
+// viewFromClause parse the table references of the FROM clause, the tables of the derived tables are unknown.
+// The parsing stops at the first clause after FROM
+func viewFromClause(p *tokenParser, tables map[string]*myTable) []*viewFrom {
+	var from []*viewFrom
+	nullable := false
+	for {
+		f := &viewFrom{nullable: nullable}
+		if p.is("(") && !p.is("(", "SELECT") && !p.is("(", "WITH") {
+			// nested join (a JOIN b ON ...), mysql shows the joins of the view in parentheses
+			start := p.pos + 1
+			if _, err := p.skipParens(); err != nil {
+				return from
+			}
+			nested := viewFromClause(&tokenParser{toks: p.toks[start : p.pos-1]}, tables)
+			for _, v := range nested {
+				v.nullable = v.nullable || nullable
+			}
+			from = append(from, nested...)
+			f = nil
+		} else if p.is("(") {
+			if _, err := p.skipParens(); err != nil {
+				return from
+			}
+		} else {
+			name, err := mysqlName(p)
+			if err != nil {
+				return from
+			}
+			f.table, f.alias = tables[name], name
+		}
+		if f != nil {
+			if p.accept("AS") {
+				f.alias = p.next().text
+			} else if t := p.peek(); t.kind == tokenQuoted || t.kind == tokenIdent && !viewKeywords[strings.ToUpper(t.text)] {
+				f.alias = p.next().text
+			}
+			from = append(from, f)
+		}
+		if p.accept("ON") || p.accept("USING") {
+			for !p.eof() && !p.is(",") && !p.is(")") && !(p.peek().kind == tokenIdent && viewKeywords[strings.ToUpper(p.peek().text)]) {
+				if p.is("(") {
+					p.skipParens()
+					continue
+				}
+				p.next()
+			}
+		}
+		nullable = false
+		switch {
+		case p.accept(","), p.accept("JOIN"), p.accept("INNER", "JOIN"), p.accept("CROSS", "JOIN"),
+			p.accept("STRAIGHT_JOIN"), p.accept("NATURAL", "JOIN"), p.accept("NATURAL", "INNER", "JOIN"):
+		case p.accept("LEFT", "JOIN"), p.accept("LEFT", "OUTER", "JOIN"), p.accept("NATURAL", "LEFT", "JOIN"),
+			p.accept("NATURAL", "LEFT", "OUTER", "JOIN"):
+			nullable = true
+		case p.accept("RIGHT", "JOIN"), p.accept("RIGHT", "OUTER", "JOIN"), p.accept("NATURAL", "RIGHT", "JOIN"),
+			p.accept("NATURAL", "RIGHT", "OUTER", "JOIN"):
+			for _, v := range from {
+				v.nullable = true
+			}
+		default:
+			return from
+		}
+	}
+}
+
+// castType returns the data type and the column type of the CAST target type: SIGNED CHAR(10) DECIMAL(10,2) DATETIME
+func castType(toks []sqlToken) (string, string, bool) {
+	if len(toks) == 0 || toks[0].kind != tokenIdent {
+		return "", "", false
+	}
+	var args string
+	if len(toks) > 1 && isPunct(toks[1], "(") {
+		for _, v := range toks[1:] {
+			args += v.raw
+			if isPunct(v, ")") {
+				break
+			}
+		}
+	}
+	switch dt := strings.ToLower(toks[0].text); dt {
+	case "signed":
+		return "bigint", "bigint", true
+	case "unsigned":
+		return "bigint", "bigint unsigned", true
+	case "char", "nchar":
+		return "varchar", "varchar" + args, true
+	case "binary":
+		return "varbinary", "varbinary" + args, true
+	case "decimal":
+		return dt, dt + args, true
+	case "double", "float", "real":
+		return "double", "double", true
+	case "date", "datetime", "time", "year", "json":
+		return dt, dt + args, true
+	}
+	return "", "", false
+}
+
+// lastAtDepth0 returns the index of the last keyword or punctuation which is not in parentheses, -1 if not found
+func lastAtDepth0(toks []sqlToken, word string) int {
+	res, depth := -1, 0
+	for i, t := range toks {
+		switch {
+		case isPunct(t, "("):
+			depth++
+		case isPunct(t, ")"):
+			depth--
+		case depth == 0 && (isKeyword(t, word) || isPunct(t, word)):
+			res = i
+		}
+	}
+	return res
+}
+
+// isColumnRef reports whether the tokens are col t.col or db.t.col
+func isColumnRef(toks []sqlToken) bool {
+	if len(toks) != 1 && len(toks) != 3 && len(toks) != 5 {
+		return false
+	}
+	for i, t := range toks {
+		if i%2 == 0 && !isName(t) || i%2 == 1 && !isPunct(t, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// isAliasAfter reports whether the token can be followed by an alias without AS: the end of an operand
+func isAliasAfter(t sqlToken) bool {
+	return t.kind == tokenIdent || t.kind == tokenQuoted || t.kind == tokenNumber || t.kind == tokenString || isPunct(t, ")")
+}
+
+func isName(t sqlToken) bool {
+	return t.kind == tokenIdent || t.kind == tokenQuoted
+}
+
+func isKeyword(t sqlToken, word string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, word)
+}
+
+func isPunct(t sqlToken, punct string) bool {
+	return t.kind == tokenPunct && t.text == punct
+}
@@ -0,0 +1,3 @@
+// Package mysqltest tests reading the tables from information_schema against an in-process mysql compatible server.
+// go-mysql-server needs a newer go than crud, so the tests are a separate module: cd internal/mysqltest && go test ./...
+package mysqltest
@@ -0,0 +1,192 @@
+package mysqltest
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gmssql "github.com/dolthub/go-mysql-server/sql"
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/hongshengjie/crud/gen"
+	"github.com/hongshengjie/crud/internal/model"
+)
+
+// schema the tables created in the server and parsed from the DDL, go-mysql-server does not keep the table comments,
+// ON UPDATE CURRENT_TIMESTAMP and the generated columns, the sqlite test of internal/model covers them.
+// mysql creates the index of the foreign key, it is declared to keep the DDL the same as the server.
+// information_schema has no order of the indexes, the primary key and the unique keys come first and the others are ordered by the name
+var schema = []string{
+	"CREATE TABLE `user` (`id` bigint NOT NULL AUTO_INCREMENT, `name` varchar(32) NOT NULL, PRIMARY KEY (`id`))",
+	"CREATE TABLE `account` (" +
+		"`id` int unsigned NOT NULL AUTO_INCREMENT COMMENT 'id字段'," +
+		"`user_id` bigint NOT NULL," +
+		"`email` varchar(255) NOT NULL DEFAULT ''," +
+		"`name` varchar(255) NOT NULL DEFAULT '' COMMENT '名称'," +
+		"`status` enum('on','off') NOT NULL DEFAULT 'on'," +
+		"`flags` tinyint unsigned NOT NULL DEFAULT '0'," +
+		"`amount` decimal(10,2) NOT NULL DEFAULT '0'," +
+		"`bio` text," +
+		"`mtime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+		"PRIMARY KEY (`id`)," +
+		"UNIQUE KEY `uk_email` (`email`)," +
+		"KEY `fk_user` (`user_id`)," +
+		"KEY `ix_name_mtime` (`name`,`mtime`)," +
+		"CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE," +
+		"CONSTRAINT `ck_name` CHECK (char_length(`name`) > 0))",
+	"CREATE TABLE `user_role` (`user_id` bigint NOT NULL, `role` varchar(16) NOT NULL, `ctime` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (`user_id`,`role`))",
+}
+
+// startServer starts an in-process mysql compatible server with the empty database test, returns the dsn of it
+func startServer(t *testing.T) string {
+	db := memory.NewDatabase("test")
+	db.BaseDatabase.EnablePrimaryKeyIndexes()
+	pro := memory.NewDBProvider(db)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := server.NewServer(server.Config{Protocol: "tcp", Listener: l}, sqle.NewDefault(pro), gmssql.NewContext, memory.NewSessionBuilder(pro), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(func() { s.Close() })
+	return fmt.Sprintf("root@tcp(%s)/test", l.Addr())
+}
+
+func TestReadSchema(t *testing.T) {
+	dsn := startServer(t)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, v := range schema {
+		if _, err := db.Exec(v); err != nil {
+			t.Fatalf("%s: %v", v, err)
+		}
+	}
+	tables, err := gen.ReadSchema(dsn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "schema.sql"), []byte(strings.Join(schema, ";\n")+";\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, err := gen.ParseFiles(model.DialectMySQL, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the tables of information_schema are ordered by the name
+	if len(tables) != 3 || tables[0].TableName != "account" || tables[1].TableName != "user" || tables[2].TableName != "user_role" {
+		t.Fatalf("tables = %d, want account user user_role", len(tables))
+	}
+	for _, w := range want {
+		var got *gen.Table
+		for _, v := range tables {
+			if v.TableName == w.TableName {
+				got = v
+			}
+		}
+		if s, exp := summary(got), summary(w); s != exp {
+			t.Errorf("information_schema table\n%s\nwant the same as ddl table\n%s", s, exp)
+		}
+	}
+	if stmts := model.DiffTables(tables, want, nil, false); len(stmts) > 0 {
+		t.Fatalf("information_schema tables differ from ddl tables\n%s", strings.Join(stmts, ";\n"))
+	}
+
+	account := tables[0]
+	model.SetExactInt(account)
+	if c := account.Fields[0]; c.GoColumnType != "uint32" || !c.IsAutoIncrment || !c.IsPrimaryKey {
+		t.Errorf("id = %s auto_increment=%v pk=%v, want uint32 primary key", c.GoColumnType, c.IsAutoIncrment, c.IsPrimaryKey)
+	}
+	if c := account.Fields[5]; c.GoColumnType != "uint8" {
+		t.Errorf("flags = %s, want uint8", c.GoColumnType)
+	}
+	if c := account.Fields[4]; c.GoColumnType != "AccountStatus" || len(c.EnumValues) != 2 || c.EnumValues[1].Value != "off" {
+		t.Errorf("status = %s %d, want the enum on off", c.GoColumnType, len(c.EnumValues))
+	}
+	if c := account.Fields[6]; c.GoColumnType != model.DecimalType || c.Precision != 10 || c.Scale != 2 {
+		t.Errorf("amount = %s %d %d, want decimal(10,2)", c.GoColumnType, c.Precision, c.Scale)
+	}
+	if fk := account.ForeignKeys; len(fk) != 1 || fk[0].OnDelete != "CASCADE" || fk[0].OnUpdate != "" {
+		t.Errorf("foreign keys = %+v, want fk_user ON DELETE CASCADE", fk)
+	}
+
+	tables, err = gen.ReadSchema(dsn, []string{"user_role", " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || len(tables[0].PrimaryKeys) != 2 {
+		t.Fatalf("tables = %d, want user_role with the composite primary key", len(tables))
+	}
+	if _, err := gen.ReadSchema(dsn, []string{"not_exist"}); err == nil {
+		t.Fatal("want error for not exist table")
+	}
+}
+
+func TestGenerateFromDSN(t *testing.T) {
+	dsn := startServer(t)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, v := range schema {
+		if _, err := db.Exec(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := &gen.Config{DSN: dsn, Output: filepath.Join(t.TempDir(), "crud")}
+	tables, err := gen.Load(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the edge of the foreign key is built for all tables of the database
+	if len(tables[0].Edges) != 1 || len(tables[1].Edges) != 1 {
+		t.Fatalf("edges = %d %d, want account -> user", len(tables[0].Edges), len(tables[1].Edges))
+	}
+	targets, err := c.AllTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := gen.Generate(tables, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 10 || filepath.Base(files[9].Path) != "aa_client.go" {
+		t.Fatalf("files = %d, want 3 files of every table and aa_client.go", len(files))
+	}
+}
+
+func summary(t *gen.Table) string {
+	s := fmt.Sprintf("%s %s pk=%s\n", t.GoTableName, t.PackageName, model.PKTool(t, "args"))
+	for _, c := range t.Fields {
+		s += fmt.Sprintf("%s %s %s %s %s notnull=%v pk=%v ai=%v dct=%v default=%s enum=%d %q\n", c.ColumnName, c.ColumnType,
+			c.GoColumnType, c.GoConditionType, c.ProtoType, c.NotNull, c.IsPrimaryKey, c.IsAutoIncrment, c.IsDefaultCurrentTimestamp, c.ColumnDefault, len(c.EnumValues), c.ColumnComment)
+	}
+	for _, v := range t.Indexes {
+		s += fmt.Sprintf("index %s %s unique=%v\n", v.Name, model.ColumnTool(v.Columns, "field"), v.Unique)
+	}
+	for _, v := range t.Finders {
+		s += fmt.Sprintf("finder %s %v %s\n", v.GoName, v.Unique, v.Index.Name)
+	}
+	for _, v := range t.ForeignKeys {
+		s += fmt.Sprintf("foreign key %s %s references %s %v on delete %q on update %q\n", v.Name, model.ColumnTool(v.Columns, "field"), v.RefTable, v.RefColumns, v.OnDelete, v.OnUpdate)
+	}
+	// the expressions rewritten by the server are compared by DiffTables
+	for _, v := range t.Checks {
+		s += fmt.Sprintf("check %s\n", v.Name)
+	}
+	return s
+}
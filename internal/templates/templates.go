@@ -0,0 +1,9 @@
+// Package templates embeds the builtin templates of the generated code
+package templates
+
+import "embed"
+
+// FS the builtin templates by the file name: model.tmpl builder.tmpl where.tmpl...
+//
+//go:embed *.tmpl
+var FS embed.FS
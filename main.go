@@ -1,63 +1,43 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 
-	"go/format"
 	"log"
 	"os"
 	"strings"
-	"text/template"
-
-	_ "embed"
-
-	_ "github.com/go-sql-driver/mysql"
 
+	"github.com/hongshengjie/crud/gen"
 	"github.com/hongshengjie/crud/internal/model"
+	"github.com/hongshengjie/crud/xsql"
+	"github.com/hongshengjie/crud/xsql/postgres"
+	"github.com/hongshengjie/crud/xsql/sqlite"
 )
 
-//go:embed "internal/templates/model.tmpl"
-var modelTmpl []byte
-
-//go:embed "internal/templates/builder.tmpl"
-var crudTmpl []byte
-
-//go:embed "internal/templates/where.tmpl"
-var whereTmpl []byte
-
-//go:embed "internal/templates/proto.tmpl"
-var protoTmpl []byte
-
-//go:embed "internal/templates/service.tmpl"
-var serviceTmpl []byte
-
-//go:embed "internal/templates/client.tmpl"
-var clientTmpl []byte
-
-//go:embed "internal/templates/react-grommet.tmpl"
-var reactGrommetTmpl []byte
-
-//go:embed "internal/templates/builder_mgo.tmpl"
-var crudMgo []byte
-
-//go:embed "internal/templates/struct2pb.tmpl"
-var struct2PB []byte
-
-var database string
-var path string
 var service bool
 var http bool
 var protopkg string
 var reactgrommet bool
 var mgo string
 var struct2pb string
+var nullable string
+var exactint bool
+var protowrapper bool
+var dsn string
+var dialect string
+var tables string
+var configFile string
+var check bool
+var dryRun bool
 
 // var fields string
-const defaultDir = "crud"
+const defaultDir = gen.DefaultDir
 
 func init() {
 	//flag.StringVar(&path, "path", "cr", ".sql file path or folder")
@@ -66,14 +46,34 @@ func init() {
 	flag.BoolVar(&reactgrommet, "reactgrommet", false, "-reactgrommet  generate reactgrommet tsx code work with -service")
 	flag.StringVar(&protopkg, "protopkg", "", "-protopkg  proto package field value")
 	flag.StringVar(&mgo, "mgo", "", "-mgo find struct from file and generate crud method example  ./user.go:User  User struct in ./user.go file ")
+	flag.StringVar(&nullable, "nullable", "", "-nullable  go type of nullable column: sql (sql.NullString...) or ptr (*string...), default same as not null column")
+	flag.BoolVar(&exactint, "exactint", false, "-exactint  keep the exact go type of integer columns: int8 uint32 uint64..., tinyint(1) as bool, default all integer columns are int64")
+	flag.BoolVar(&protowrapper, "protowrapper", false, "-protowrapper  nullable column use google.protobuf wrapper type in proto message work with -service")
+	flag.StringVar(&dialect, "dialect", model.DialectMySQL, "-dialect  sql dialect of the .sql files and generated code: mysql or postgres, crud migrate also supports sqlite")
+	flag.StringVar(&dsn, "dsn", "", "-dsn  read tables from information_schema of a live mysql database instead of .sql files  user:pwd@tcp(127.0.0.1:3306)/test")
+	flag.StringVar(&tables, "tables", "", "-tables  comma separated table names work with -dsn, default all tables of the database")
+	flag.BoolVar(&check, "check", false, "-check  print the unified diff of the generated code and the files on disk, exit 1 when they are out of date")
+	flag.BoolVar(&dryRun, "dry-run", false, "-dry-run  list the files which would be created or updated without writing them")
+	flag.StringVar(&configFile, "config", "crud.yaml", "-config  project config file, the flags override it")
 	flag.StringVar(&struct2pb, "struct2pb", "", "-struct2pb find struct from file and generate corresponding proto message  ./user.go:User  User struct in ./user.go file ")
 }
 
 func main() {
 
 	flag.Parse()
+	required := false
+	flag.Visit(func(f *flag.Flag) { required = required || f.Name == "config" })
+	loadConfig(configFile, required)
 
 	// subcommand
+	switch flag.Arg(0) {
+	case "diff":
+		diffSchema(flag.Args()[1:])
+		return
+	case "migrate":
+		migrate(flag.Args()[1:])
+		return
+	}
 	if len(os.Args) == 2 {
 		switch os.Args[1] {
 		case "init":
@@ -84,18 +84,16 @@ func main() {
 			return
 		}
 	}
-	if len(os.Args) == 1 {
-		info, err := os.Stat(defaultDir)
-		if err != nil {
-			if os.IsNotExist(err) {
-				log.Fatal("crud dir is not exist please exec: crud init")
+	if len(os.Args) == 1 && dsn == "" {
+		for _, v := range config.Inputs {
+			if _, err := os.Stat(v); err != nil {
+				if os.IsNotExist(err) {
+					log.Fatalf("%s dir is not exist please exec: crud init", v)
+					return
+				}
+				log.Fatal(err)
 				return
 			}
-			log.Fatal(err)
-			return
-		}
-		if info.IsDir() {
-			path = defaultDir
 		}
 	}
 
@@ -107,7 +105,15 @@ func main() {
 		filePath := pathName[0]
 		structName := pathName[1]
 		doc := model.ParseMongoStruct(filePath, structName)
-		generateFile(filePath, string(crudMgo), nil, doc)
+		tmpl, err := config.Template("builder_mgo.tmpl")
+		if err != nil {
+			log.Fatal(err)
+		}
+		content, err := gen.Execute(filePath, tmpl, doc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		writeFile(gen.File{Path: filePath, Content: content})
 		return
 	}
 	if struct2pb != "" {
@@ -118,92 +124,181 @@ func main() {
 		filePath := pathName[0]
 		structName := pathName[1]
 		message := model.ParseStruct(filePath, structName)
-		tpl, err := template.New("").Funcs(f).Parse(string(struct2PB))
+		tmpl, err := config.Template("struct2pb.tmpl")
 		if err != nil {
 			log.Fatalln(err)
 		}
-		err = tpl.Execute(os.Stdout, message)
+		content, err := gen.Execute("", tmpl, message)
 		if err != nil {
 			log.Fatalln(err)
 		}
+		os.Stdout.Write(content)
 
 		return
 	}
-	if path == "" {
-		path = defaultDir
+	tableObjs, err := gen.Load(config)
+	if err != nil {
+		log.Fatal(err)
 	}
-	tableObjs, isDir := tableFromSql(path)
-	for _, v := range tableObjs {
-		generateFiles(v)
+	targets, err := config.AllTargets()
+	if err != nil {
+		log.Fatal(err)
 	}
-	if isDir && path == defaultDir {
-		generateFile(filepath.Join(defaultDir, "aa_client.go"), string(clientTmpl), f, tableObjs)
+	files, err := gen.Generate(tableObjs, targets)
+	if err != nil {
+		log.Fatal(err)
 	}
-
+	for _, v := range files {
+		writeFile(v)
+	}
+	// -check and -dry-run do not run protoc, the .pb.go files are not compared
+	for _, v := range files {
+		if strings.HasSuffix(v.Path, ".api.proto") && !check && !dryRun {
+			protoc(v.Path, strings.TrimSuffix(filepath.Base(v.Path), ".api.proto"))
+		}
+	}
+	finishCheck(config.WholeSchema())
 }
 
-func tableFromSql(path string) (tableObjs []*model.Table, isDir bool) {
-	relativePath := model.GetRelativePath()
-	info, err := os.Stat(path)
+// tableFromSql parse the tables of the .sql file or folder by the dialect
+func tableFromSql(path string) []*model.Table {
+	tableObjs, err := gen.ParseFiles(dialect, path)
 	if err != nil {
 		log.Fatal(err)
 	}
-	if info.IsDir() {
-		isDir = true
-		fs, err := ioutil.ReadDir(path)
-		if err != nil {
-			log.Fatal(err)
-		}
-		for _, v := range fs {
-			if !v.IsDir() && strings.HasSuffix(strings.ToLower(v.Name()), ".sql") {
-				obj := model.MysqlTable(database, filepath.Join(path, v.Name()), relativePath)
-				if obj != nil {
-					tableObjs = append(tableObjs, obj)
-				}
-
-			}
+	return tableObjs
+}
 
-		}
-	} else {
-		tableObjs = append(tableObjs, model.MysqlTable(database, path, relativePath))
+// schemaFromDSN read the tables from information_schema, all tables of the database when tables is empty
+func schemaFromDSN(dsn, tables string) []*model.Table {
+	tableObjs, err := gen.ReadSchema(dsn, strings.Split(tables, ","))
+	if err != nil {
+		log.Fatal(err)
 	}
-	return tableObjs, isDir
+	return tableObjs
 }
 
-var f = template.FuncMap{
-	"sqltool":                        model.SQLTool,
-	"isnumber":                       model.IsNumber,
-	"Incr":                           model.Incr,
-	"GoTypeToTypeScriptDefaultValue": model.GoTypeToTypeScriptDefaultValue,
+// diffSchema print the migration statements from the current schema to the .sql files
+//
+//	crud diff -from old.sql
+//	crud diff -dsn "user:pwd@tcp(127.0.0.1:3306)/test" -rename user:account
+func diffSchema(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	to := fs.String("to", defaultDir, "-to  .sql file or folder of the desired schema")
+	from := fs.String("from", "", "-from  .sql file or folder of the current schema")
+	fromDSN := fs.String("dsn", "", "-dsn  read the current schema from information_schema of a live mysql database instead of -from")
+	rename := fs.String("rename", "", "-rename  comma separated renamed tables  old_name:new_name")
+	drop := fs.Bool("drop", false, "-drop  drop the tables which are not in the desired schema")
+	fs.Parse(args)
+
+	renames := map[string]string{}
+	for _, v := range strings.Split(*rename, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		names := strings.Split(v, ":")
+		if len(names) != 2 {
+			log.Fatalf("-rename not right example old_name:new_name")
+		}
+		renames[names[0]] = names[1]
+	}
+	desired := tableFromSql(*to)
+	var current []*model.Table
+	switch {
+	case *fromDSN != "":
+		if dialect != model.DialectMySQL {
+			log.Fatalf("-dsn only support mysql")
+		}
+		current = schemaFromDSN(*fromDSN, "")
+	case *from != "":
+		current = tableFromSql(*from)
+	default:
+		log.Fatal("crud diff need the current schema  -from old.sql or -dsn user:pwd@tcp(127.0.0.1:3306)/test")
+	}
+	for _, v := range model.DiffTables(current, desired, renames, *drop) {
+		fmt.Println(v + ";")
+	}
 }
 
-func generateFiles(tableObj *model.Table) {
-
-	//创建目录
-	dir := filepath.Join(defaultDir, tableObj.PackageName)
-	os.Mkdir(dir, os.ModePerm)
-	generateFile(filepath.Join(dir, "model.go"), string(modelTmpl), f, tableObj)
-	generateFile(filepath.Join(dir, "where.go"), string(whereTmpl), f, tableObj)
-	generateFile(filepath.Join(dir, "builder.go"), string(crudTmpl), f, tableObj)
-	if service {
-		generateService(tableObj)
+// migrate apply the migrations of the folder to the database
+//
+//	crud migrate -dsn "user:pwd@tcp(127.0.0.1:3306)/test" up
+//	crud migrate -dir db/migrations -dsn "user:pwd@tcp(127.0.0.1:3306)/test" goto 3
+func migrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("dir", "migrations", "-dir  folder of the NNN_name.up.sql and NNN_name.down.sql files")
+	migrateDSN := fs.String("dsn", "", "-dsn  data source name of the database to migrate")
+	outOfOrder := fs.Bool("out-of-order", false, "-out-of-order  apply the pending migrations older than the latest applied one")
+	fs.Parse(args)
+	if *migrateDSN == "" || fs.NArg() == 0 {
+		log.Fatal("crud migrate -dsn user:pwd@tcp(127.0.0.1:3306)/test up|down|status|goto N")
+	}
+	config := &xsql.Config{DSN: *migrateDSN, Active: 1, Idle: 1}
+	var db *xsql.DB
+	var err error
+	switch dialect {
+	case model.DialectMySQL:
+		db, err = xsql.NewMySQL(config)
+	case model.DialectPostgres:
+		db, err = postgres.New(config)
+	case xsql.SQLite:
+		db, err = sqlite.New(config)
+	default:
+		log.Fatalf("-dialect %s not support", dialect)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator, err := xsql.NewMigrator(db, os.DirFS(*dir))
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator.AllowOutOfOrder = *outOfOrder
+	ctx := context.Background()
+	var done []*xsql.Migration
+	switch fs.Arg(0) {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx)
+	case "goto":
+		var version int64
+		if version, err = strconv.ParseInt(fs.Arg(1), 10, 64); err != nil || version < 0 {
+			log.Fatal("crud migrate goto N  N is the version to migrate to, 0 roll back all")
+		}
+		done, err = migrator.Goto(ctx, version)
+	case "status":
+		var status []*xsql.MigrationStatus
+		if status, err = migrator.Status(ctx); err != nil {
+			log.Fatal(err)
+		}
+		for _, v := range status {
+			applied := "pending"
+			if v.Applied {
+				applied = v.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%d_%s\t%s\n", v.Version, v.Name, applied)
+		}
+		return
+	default:
+		log.Fatalf("crud migrate %s not support, up|down|status|goto N", fs.Arg(0))
+	}
+	for _, v := range done {
+		fmt.Printf("migrated %d_%s\n", v.Version, v.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
-
 }
-func generateService(tableObj *model.Table) {
-	pkgName := tableObj.PackageName
-	tableObj.Protopkg = protopkg
-	os.Mkdir(filepath.Join("proto"), os.ModePerm)
-	os.Mkdir(filepath.Join("service"), os.ModePerm)
-
-	generateFile(filepath.Join("proto", pkgName+".api.proto"), string(protoTmpl), f, tableObj)
 
+// protoc generate the go code of the proto file
+func protoc(protoFile, pkgName string) {
 	// proto-go  grpc
 	var cmd *exec.Cmd
 	if http {
-		cmd = exec.Command("protoc", "-I.", "--go_out=.", "--go-grpc_out=.", "--go-gin_out=.", filepath.Join("proto", pkgName+".api.proto"))
+		cmd = exec.Command("protoc", "-I.", "--go_out=.", "--go-grpc_out=.", "--go-gin_out=.", protoFile)
 	} else {
-		cmd = exec.Command("protoc", "-I.", "--go_out=.", "--go-grpc_out=.", filepath.Join("proto", pkgName+".api.proto"))
+		cmd = exec.Command("protoc", "-I.", "--go_out=.", "--go-grpc_out=.", protoFile)
 	}
 
 	cmd.Dir = filepath.Join(model.GetCurrentPath())
@@ -220,36 +315,18 @@ func generateService(tableObj *model.Table) {
 	if err != nil {
 		log.Println(string(s), err)
 	}
-
-	generateFile(filepath.Join("service", pkgName+".service.go"), string(serviceTmpl), f, tableObj)
-
-	if reactgrommet {
-		generateFile(filepath.Join("web", "src", "pages", pkgName+".tsx"), string(reactGrommetTmpl), f, tableObj)
-	}
 }
 
-func generateFile(filename, tmpl string, f template.FuncMap, data interface{}) {
-	tpl, err := template.New(filename).Funcs(f).Parse(string(tmpl))
-	if err != nil {
-		log.Fatalln(err)
+// writeFile write the generated file, -check and -dry-run compare it with the file on disk instead
+func writeFile(file gen.File) {
+	if check || dryRun {
+		checkFile(file.Path, file.Content)
+		return
 	}
-	bs := bytes.NewBuffer(nil)
-	err = tpl.Execute(bs, data)
-	if err != nil {
+	if err := os.MkdirAll(filepath.Dir(file.Path), os.ModePerm); err != nil {
 		log.Fatalln(err)
 	}
-
-	result := bs.Bytes()
-	if strings.HasSuffix(filename, ".go") {
-		result, err = format.Source(bs.Bytes())
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-	file, err := os.Create(filename)
-	if err != nil {
+	if err := ioutil.WriteFile(file.Path, file.Content, 0666); err != nil {
 		log.Fatalln(err)
 	}
-	file.Write(result)
-	file.Close()
 }
@@ -28,8 +28,10 @@ type InsertBuilder struct {
 	defaults string
 	values   [][]interface{}
 	// OnDuplicateKeyUpdate Expr
-	updateExpr []string
-	updateArgs []interface{}
+	updates   []Querier
+	conflict  []string
+	returning []string
+	ignore    bool
 }
 
 // Insert creates a builder for the `INSERT INTO` statement.
@@ -78,37 +80,84 @@ func (i *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
 }
 
 // OnDuplicateKeyUpdate UpdateColumns generate like  "ON DUPLICATE KEY UPDATE `id` = VALUES (`id`) " sql statement
+// for postgres and sqlite generate like `ON CONFLICT ("id") DO UPDATE SET "name" = "excluded"."name"`, the conflict target is set by ConflictColumns
 func (i *InsertBuilder) OnDuplicateKeyUpdate(columns ...string) *InsertBuilder {
-	if len(columns) > 0 {
-		b := &Builder{}
-		for j, v := range columns {
-			if j > 0 {
-				b.Comma()
+	for _, v := range columns {
+		column := v
+		i.updates = append(i.updates, P(func(b *Builder) {
+			b.Ident(column).WriteOp(OpEQ)
+			if b.postgres() || b.sqlite() {
+				// "id" = "excluded"."id"
+				b.Ident("excluded").WriteByte('.').Ident(column)
+				return
 			}
 			// `id` = VALUES (`id`)
-			b.Ident(v).WriteOp(OpEQ).WriteString("VALUES ")
+			b.WriteString("VALUES ")
 			b.Nested(func(bb *Builder) {
-				bb.Ident(v)
+				bb.Ident(column)
 			})
-		}
-		i.updateExpr = append(i.updateExpr, b.String())
+		}))
 	}
 	return i
 }
 
 // OnDuplicateKeyUpdateExpr OnDuplicateKeyUpdateExpr generate like "ON DUPLICATE KEY UPDATE `c` = VALUES(`a`) + Values(`b`)"
 func (i *InsertBuilder) OnDuplicateKeyUpdateExpr(q ...Querier) *InsertBuilder {
-	for _, v := range q {
-		expr, args := v.Query()
-		i.updateExpr = append(i.updateExpr, expr)
-		i.updateArgs = append(i.updateArgs, args...)
-	}
+	i.updates = append(i.updates, q...)
+	return i
+}
+
+// ConflictColumns sets the conflict target of `ON CONFLICT (columns) DO UPDATE` for postgres and sqlite, mysql ignore it.
+// sqlite update on any unique constraint conflict like mysql when it is not set.
+func (i *InsertBuilder) ConflictColumns(columns ...string) *InsertBuilder {
+	i.conflict = append(i.conflict, columns...)
+	return i
+}
+
+// Returning adds the `RETURNING` clause to the insert statement, it is supported by postgres and sqlite, mysql ignore it.
+func (i *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	i.returning = columns
 	return i
 }
 
+// Ignore skip the rows which conflict with the existing rows.
+// INSERT IGNORE for mysql, INSERT OR IGNORE for sqlite, ON CONFLICT DO NOTHING for postgres.
+func (i *InsertBuilder) Ignore() *InsertBuilder {
+	i.ignore = true
+	return i
+}
+
+// DefaultValue returns DEFAULT which let the database fill the column by its DEFAULT in VALUES.
+// sqlite does not support it, leave the column out of the insert instead.
+func DefaultValue() Querier {
+	return P(func(b *Builder) {
+		b.WriteString("DEFAULT")
+	})
+}
+
+// GeneratedKey returns the value let the database generate the auto increment primary key in VALUES,
+// DEFAULT for mysql and postgres, NULL for sqlite which generate the INTEGER PRIMARY KEY by NULL.
+// Only use it for the auto increment primary key, NULL of the other columns is not their DEFAULT on sqlite.
+func GeneratedKey() Querier {
+	return P(func(b *Builder) {
+		if b.sqlite() {
+			b.WriteString("NULL")
+			return
+		}
+		b.WriteString("DEFAULT")
+	})
+}
+
 // Query returns query representation of an `INSERT INTO` statement.
 func (i *InsertBuilder) Query() (string, []interface{}) {
-	i.WriteString("INSERT INTO ")
+	switch {
+	case i.ignore && i.sqlite():
+		i.WriteString("INSERT OR IGNORE INTO ")
+	case i.ignore && !i.postgres():
+		i.WriteString("INSERT IGNORE INTO ")
+	default:
+		i.WriteString("INSERT INTO ")
+	}
 	i.writeSchema(i.schema)
 	i.Ident(i.table).Pad()
 	if i.defaults != "" && len(i.columns) == 0 {
@@ -126,21 +175,29 @@ func (i *InsertBuilder) Query() (string, []interface{}) {
 				b.Args(v...)
 			})
 		}
-		if len(i.updateExpr) > 0 {
-			i.WriteString(" ON DUPLICATE KEY UPDATE ")
-			if len(i.updateExpr) > 0 {
-				for j, v := range i.updateExpr {
-					if j > 0 {
-						i.Comma()
-					}
-					i.WriteString(v)
+		if i.ignore && i.postgres() {
+			i.WriteString(" ON CONFLICT DO NOTHING")
+		}
+		if len(i.updates) > 0 {
+			if i.postgres() || i.sqlite() {
+				i.WriteString(" ON CONFLICT ")
+				if len(i.conflict) > 0 {
+					i.Nested(func(b *Builder) {
+						b.IdentComma(i.conflict...)
+					}).Pad()
 				}
-				i.args = append(i.args, i.updateArgs...)
+				i.WriteString("DO UPDATE SET ")
+			} else {
+				i.WriteString(" ON DUPLICATE KEY UPDATE ")
 			}
-
+			i.JoinComma(i.updates...)
 		}
 
 	}
+	if len(i.returning) > 0 && (i.postgres() || i.sqlite()) {
+		i.WriteString(" RETURNING ")
+		i.IdentComma(i.returning...)
+	}
 
 	statement, args := i.String(), i.args
 	return statement, args
@@ -437,7 +494,7 @@ func LT(col string, value interface{}) *Predicate {
 func (p *Predicate) LT(col string, arg interface{}) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpLT)
+		b.WriteOp(OpLT)
 		b.Arg(arg)
 	})
 }
@@ -451,7 +508,7 @@ func LTE(col string, value interface{}) *Predicate {
 func (p *Predicate) LTE(col string, arg interface{}) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpLTE)
+		b.WriteOp(OpLTE)
 		b.Arg(arg)
 	})
 }
@@ -465,7 +522,7 @@ func GT(col string, value interface{}) *Predicate {
 func (p *Predicate) GT(col string, arg interface{}) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpGT)
+		b.WriteOp(OpGT)
 		b.Arg(arg)
 	})
 }
@@ -479,7 +536,7 @@ func GTE(col string, value interface{}) *Predicate {
 func (p *Predicate) GTE(col string, arg interface{}) *Predicate {
 	return p.Append(func(b *Builder) {
 		b.Ident(col)
-		p.WriteOp(OpGTE)
+		b.WriteOp(OpGTE)
 		b.Arg(arg)
 	})
 }
@@ -1353,6 +1410,8 @@ func (s *Selector) Clone() *Selector {
 		group:    append([]string{}, s.group...),
 		order:    append([]interface{}{}, s.order...),
 		columns:  append([]string{}, s.columns...),
+		lock:     s.lock,
+		index:    s.index,
 	}
 }
 
@@ -1378,6 +1437,12 @@ func (s *Selector) OrderBy(columns ...string) *Selector {
 	return s
 }
 
+// ClearOrder clears the `ORDER BY` clause of the `SELECT` statement.
+func (s *Selector) ClearOrder() *Selector {
+	s.order = nil
+	return s
+}
+
 // OrderExpr appends the `ORDER BY` clause to the `SELECT`
 // statement with custom list of expressions.
 func (s *Selector) OrderExpr(exprs ...Querier) *Selector {
@@ -1477,7 +1542,8 @@ func (s *Selector) Query() (string, []interface{}) {
 }
 
 func (s *Selector) joinIndex(b *Builder) {
-	if len(s.index.indexName) == 0 {
+	// index hints are mysql only
+	if len(s.index.indexName) == 0 || b.postgres() || b.sqlite() {
 		return
 	}
 	b.Pad()
@@ -1509,16 +1575,16 @@ func (s *Selector) joinOrder(b *Builder) {
 	}
 }
 func (s *Selector) joinLock(b *Builder) {
-	if s.lock == nil {
+	// sqlite lock the whole database, no row lock
+	if s.lock == nil || b.sqlite() {
 		return
 	}
 	b.Pad()
-	switch s.lock.Strength {
-	case LockShare:
+	switch {
+	case s.lock.Strength == LockShare && !b.postgres():
 		b.WriteString("LOCK IN SHARE MODE")
-	case LockUpdate:
+	default:
 		b.WriteString("FOR ").WriteString(string(s.lock.Strength))
-
 	}
 	if s.lock.Action != "" {
 		b.Pad().WriteString(string(s.lock.Action))
@@ -1575,7 +1641,12 @@ type Builder struct {
 // on the configured dialect. It defaults to "`".
 func (b *Builder) Quote(ident string) string {
 	switch {
-
+	case b.postgres():
+		// If it was quoted with the wrong identifier character.
+		if strings.Contains(ident, "`") {
+			return strings.ReplaceAll(ident, "`", `"`)
+		}
+		return fmt.Sprintf(`"%s"`, ident)
 	// An identifier for unknown dialect.
 	case b.dialect == "" && strings.ContainsAny(ident, "`\""):
 		return ident
@@ -1590,6 +1661,10 @@ func (b *Builder) Ident(s string) *Builder {
 	case len(s) == 0:
 	case s != "*" && !b.isIdent(s) && !isFunc(s) && !isModifier(s):
 		b.WriteString(b.Quote(s))
+	case (isFunc(s) || isModifier(s)) && b.postgres():
+		// Modifiers and aggregation functions that
+		// were called without dialect information.
+		b.WriteString(strings.ReplaceAll(s, "`", `"`))
 	default:
 		b.WriteString(s)
 	}
@@ -1807,11 +1882,19 @@ func (b *Builder) Arg(a interface{}) *Builder {
 	case Querier:
 		b.Join(a)
 		return b
+	case *Decimal:
+		// FormatParam of the nil pointer panics, NULL needs no cast
+		if a == nil {
+			return b.Arg(nil)
+		}
 	}
 	b.total++
 	b.args = append(b.args, a)
 	// Default placeholder param (MySQL and SQLite).
 	param := "?"
+	if b.postgres() {
+		param = "$" + strconv.Itoa(b.total)
+	}
 
 	if f, ok := a.(ParamFormatter); ok {
 		param = f.FormatParam(param, &StmtInfo{
@@ -1927,9 +2010,22 @@ func (b Builder) clone() Builder {
 
 // isIdent reports if the given string is a dialect identifier.
 func (b *Builder) isIdent(s string) bool {
+	if b.postgres() {
+		return strings.Contains(s, `"`)
+	}
 	return strings.Contains(s, "`")
 }
 
+// postgres reports if the builder dialect is postgres.
+func (b Builder) postgres() bool {
+	return b.dialect == Postgres
+}
+
+// sqlite reports if the builder dialect is sqlite.
+func (b Builder) sqlite() bool {
+	return b.dialect == SQLite
+}
+
 // state wraps the all methods for setting and getting
 // update state between all queries in the query tree.
 type state interface {
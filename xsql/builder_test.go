@@ -0,0 +1,20 @@
+package xsql
+
+import "testing"
+
+func TestSelectorClone(t *testing.T) {
+	for _, c := range []struct {
+		p    *Predicate
+		want string
+	}{
+		{LT("a", 1), "`a` < ?"},
+		{LTE("a", 1), "`a` <= ?"},
+		{GT("a", 1), "`a` > ?"},
+		{GTE("a", 1), "`a` >= ?"},
+	} {
+		s := Select("id").From(Table("t")).Where(c.p)
+		if query, _ := s.Clone().Where(EQ("b", 2)).Query(); query != "SELECT `id` FROM `t` WHERE "+c.want+" AND `b` = ?" {
+			t.Errorf("Query of the clone = %s, want %s", query, c.want)
+		}
+	}
+}
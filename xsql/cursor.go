@@ -0,0 +1,127 @@
+package xsql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrInvalidCursor is returned when the cursor is malformed, signed by another key or made for another Keyset.
+var ErrInvalidCursor = errors.New("xsql: invalid cursor")
+
+// ErrNoCursorKey is returned by the cursors before SetCursorKey is called.
+var ErrNoCursorKey = errors.New("xsql: cursor key is not set, call SetCursorKey")
+
+// cursorKey holds the []byte key signing the cursors
+var cursorKey atomic.Value
+
+// SetCursorKey sets the secret key signing the cursors, the cursors are rejected by ErrNoCursorKey until it is set.
+// Set the same key on every instance and after every restart which serve the same cursors, it is safe to call concurrently.
+func SetCursorKey(key []byte) {
+	cursorKey.Store(append([]byte(nil), key...))
+}
+
+// Keyset is an order of the cursor pagination, the columns of an index followed by the primary key as the tie-breaker.
+// The next page is read by comparing the columns with the position of the last record instead of OFFSET:
+//
+//	WHERE (`mtime`, `id`) > (?, ?) ORDER BY `mtime` ASC, `id` ASC LIMIT 20
+//
+// The cursor is the opaque and signed position, the changed one is rejected by ErrInvalidCursor.
+// The columns must be NOT NULL, the NULL can not be compared.
+type Keyset struct {
+	Name    string   // name of the order, such as table.index, the cursor of one order is rejected by the others
+	Columns []string // the ordering columns end with the primary key
+}
+
+// Encode returns the cursor of the position, values are the values of the Columns
+func (k Keyset) Encode(values ...interface{}) (string, error) {
+	if len(values) != len(k.Columns) {
+		return "", fmt.Errorf("xsql: cursor of %s needs %d values, got %d", k.Name, len(k.Columns), len(values))
+	}
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	key, err := signingKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, k.sign(key, payload)...)), nil
+}
+
+// Decode verifies the cursor and stores the values of the Columns in dst which are pointers
+func (k Keyset) Decode(cursor string, dst ...interface{}) error {
+	key, err := signingKey()
+	if err != nil {
+		return err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) < sha256.Size {
+		return ErrInvalidCursor
+	}
+	payload, sum := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	if !hmac.Equal(sum, k.sign(key, payload)) {
+		return ErrInvalidCursor
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(payload, &values); err != nil || len(values) != len(dst) {
+		return ErrInvalidCursor
+	}
+	for i, v := range values {
+		if err := json.Unmarshal(v, dst[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// After decodes the cursor into dst and returns the predicate of the records after it in the ascending order
+func (k Keyset) After(cursor string, dst ...interface{}) (*Predicate, error) {
+	if err := k.Decode(cursor, dst...); err != nil {
+		return nil, err
+	}
+	return CompositeGT(k.Columns, indirect(dst)...), nil
+}
+
+// Before decodes the cursor into dst and returns the predicate of the records before it in the descending order
+func (k Keyset) Before(cursor string, dst ...interface{}) (*Predicate, error) {
+	if err := k.Decode(cursor, dst...); err != nil {
+		return nil, err
+	}
+	return CompositeLT(k.Columns, indirect(dst)...), nil
+}
+
+// sign the payload with the name and columns of the keyset
+func (k Keyset) sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(k.Name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.Join(k.Columns, ",")))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// signingKey returns the key set by SetCursorKey
+func signingKey() ([]byte, error) {
+	key, _ := cursorKey.Load().([]byte)
+	if len(key) == 0 {
+		return nil, ErrNoCursorKey
+	}
+	return key, nil
+}
+
+// indirect returns the values the pointers point to
+func indirect(ptrs []interface{}) []interface{} {
+	values := make([]interface{}, len(ptrs))
+	for i, v := range ptrs {
+		values[i] = reflect.ValueOf(v).Elem().Interface()
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+package xsql
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	defer SetCursorKey(nil)
+	SetCursorKey(nil)
+	k := Keyset{Name: "user.ix_mtime", Columns: []string{"mtime", "id"}}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := k.Encode(mtime, int64(7)); err != ErrNoCursorKey {
+		t.Fatalf("Encode without key = %v", err)
+	}
+	var gotMtime time.Time
+	var gotID int64
+	if err := k.Decode("x", &gotMtime, &gotID); err != ErrNoCursorKey {
+		t.Fatalf("Decode without key = %v", err)
+	}
+
+	key := []byte("secret")
+	SetCursorKey(key)
+	key[0] = 'x' // the key is copied
+	cursor, err := k.Encode(mtime, int64(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Decode(cursor, &gotMtime, &gotID); err != nil || !gotMtime.Equal(mtime) || gotID != 7 {
+		t.Fatalf("Decode = %v %d, %v", gotMtime, gotID, err)
+	}
+	p, err := k.After(cursor, &gotMtime, &gotID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query, args := Select("id").From(Table("user")).Where(p).Query(); query != "SELECT `id` FROM `user` WHERE (`mtime`, `id`) > (?, ?)" || len(args) != 2 || args[1] != int64(7) {
+		t.Fatalf("After = %s %v", query, args)
+	}
+	if _, err := k.Encode(mtime); err == nil {
+		t.Fatalf("Encode of 1 value = nil, want error")
+	}
+
+	b, _ := base64.RawURLEncoding.DecodeString(cursor)
+	tampered := append([]byte(nil), b...)
+	tampered[len(tampered)-40]++ // a byte of the payload before the signature
+	for _, c := range []struct {
+		name   string
+		k      Keyset
+		cursor string
+	}{
+		{"tampered", k, base64.RawURLEncoding.EncodeToString(tampered)},
+		{"truncated", k, cursor[:20]},
+		{"not base64", k, cursor + "!"},
+		{"empty", k, ""},
+		{"another order", Keyset{Name: "user.PRIMARY", Columns: []string{"mtime", "id"}}, cursor},
+		{"another columns", Keyset{Name: k.Name, Columns: []string{"ctime", "id"}}, cursor},
+	} {
+		if err := c.k.Decode(c.cursor, &gotMtime, &gotID); err != ErrInvalidCursor {
+			t.Errorf("Decode of the %s cursor = %v", c.name, err)
+		}
+	}
+	if err := k.Decode(cursor, &gotID); err != ErrInvalidCursor {
+		t.Errorf("Decode into 1 value = %v", err)
+	}
+	if err := k.Decode(cursor, &gotID, &gotMtime); err != ErrInvalidCursor {
+		t.Errorf("Decode into the wrong types = %v", err)
+	}
+
+	SetCursorKey([]byte("another secret"))
+	if err := k.Decode(cursor, &gotMtime, &gotID); err != ErrInvalidCursor {
+		t.Errorf("Decode by the wrong key = %v", err)
+	}
+	SetCursorKey([]byte("secret"))
+	if err := k.Decode(cursor, &gotMtime, &gotID); err != nil {
+		t.Errorf("Decode by the same key of another instance = %v", err)
+	}
+}
@@ -30,7 +30,13 @@ type Config struct {
 }
 
 func NewMySQL(c *Config) (*DB, error) {
-	m, err := connect(c.DSN, c.Active, c.Idle, c.IdleTimeout)
+	return Open(MySQL, c)
+}
+
+// Open opens the db of the dialect, the database/sql driver of the same name must be registered.
+// The postgres and sqlite drivers are registered by xsql/postgres and xsql/sqlite, only the programs importing them link the drivers
+func Open(dialect string, c *Config) (*DB, error) {
+	m, err := connect(dialect, c.DSN, c.Active, c.Idle, c.IdleTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -39,23 +45,24 @@ func NewMySQL(c *Config) (*DB, error) {
 		rs = append(rs, m)
 	}
 	for _, v := range c.ReadDSN {
-		r, err := connect(v, c.Active, c.Idle, c.IdleTimeout)
+		r, err := connect(dialect, v, c.Active, c.Idle, c.IdleTimeout)
 		if err != nil {
 			return nil, err
 		}
 		rs = append(rs, r)
 	}
 	db := &DB{
-		master: m,
-		slaves: rs,
-		config: c,
+		master:  m,
+		slaves:  rs,
+		config:  c,
+		dialect: dialect,
 	}
 
 	return db, nil
 }
 
-func connect(dsn string, active, idle int, idleTimeout time.Duration) (*sql.DB, error) {
-	m, err := sql.Open("mysql", dsn)
+func connect(driverName, dsn string, active, idle int, idleTimeout time.Duration) (*sql.DB, error) {
+	m, err := sql.Open(driverName, dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -66,10 +73,16 @@ func connect(dsn string, active, idle int, idleTimeout time.Duration) (*sql.DB,
 }
 
 type DB struct {
-	master *sql.DB
-	slaves []*sql.DB
-	idx    int64
-	config *Config
+	master  *sql.DB
+	slaves  []*sql.DB
+	idx     int64
+	config  *Config
+	dialect string
+}
+
+// Dialect returns the dialect of the database, MySQL Postgres or SQLite
+func (db *DB) Dialect() string {
+	return db.dialect
 }
 
 func (db *DB) Master() *sql.DB {
@@ -104,3 +117,12 @@ func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
 	return db.master.BeginTx(ctx, opts)
 }
+
+// Primary returns the querier which reads from the master if eq is a *DB,
+// such as reading the record just written, other queriers are returned as is.
+func Primary(eq ExecQuerier) ExecQuerier {
+	if db, ok := eq.(*DB); ok {
+		return WithDialect(db.master, db.dialect)
+	}
+	return eq
+}
@@ -0,0 +1,320 @@
+package xsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number for the DECIMAL and NUMERIC columns, the value is unscaled * 10^-scale.
+// The zero value is 0. Decimal is immutable, the arithmetic methods return a new value.
+//
+//	price := xsql.MustDecimal("19.99")
+//	total := price.Mul(xsql.NewDecimal(3, 0)).Add(xsql.MustDecimal("0.03")) // 60.00
+type Decimal struct {
+	unscaled *big.Int // nil is 0
+	scale    int32
+}
+
+// NewDecimal returns the decimal unscaled * 10^-scale. NewDecimal(1999, 2) is 19.99
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal parses the decimal string like 12, -0.50, 1.5e3 exactly.
+func ParseDecimal(s string) (Decimal, error) {
+	orig := s
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("xsql: invalid decimal %q", orig)
+		}
+		if e > 1<<16 || e < -1<<16 {
+			return Decimal{}, fmt.Errorf("xsql: decimal %q out of range", orig)
+		}
+		exp, s = e, s[:i]
+	}
+	neg := false
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg, s = s[0] == '-', s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i+1:]
+	}
+	digits := intPart + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("xsql: invalid decimal %q", orig)
+	}
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+	scale := int64(len(frac)) - exp
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(int32(-scale)))
+		scale = 0
+	}
+	if scale > 1<<16 {
+		return Decimal{}, fmt.Errorf("xsql: decimal %q out of range", orig)
+	}
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// MustDecimal is like ParseDecimal but panics if the string can not be parsed.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of d at the scale, the scale must not be less than the scale of d
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return new(big.Int).Set(d.int())
+	}
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1 if d < 0, 0 if d is 0, +1 if d > 0
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and o by value, returns -1 if d < o, 0 if d == o, +1 if d > o. 1.50 equals to 1.5
+func (d Decimal) Cmp(o Decimal) int {
+	s := maxScale(d, o)
+	return d.rescale(s).Cmp(o.rescale(s))
+}
+
+// Equal reports whether d and o are the same value
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+// Add returns d + o, the scale is the larger scale of d and o
+func (d Decimal) Add(o Decimal) Decimal {
+	s := maxScale(d, o)
+	return Decimal{unscaled: new(big.Int).Add(d.rescale(s), o.rescale(s)), scale: s}
+}
+
+// Sub returns d - o, the scale is the larger scale of d and o
+func (d Decimal) Sub(o Decimal) Decimal {
+	s := maxScale(d, o)
+	return Decimal{unscaled: new(big.Int).Sub(d.rescale(s), o.rescale(s)), scale: s}
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Mul returns d * o, the scale is the sum of the scales of d and o
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Div returns d / o rounded half away from zero to the scale, it panics if o is 0
+func (d Decimal) Div(o Decimal, scale int32) Decimal {
+	if o.IsZero() {
+		panic("xsql: decimal division by zero")
+	}
+	// d / o = (d.unscaled * 10^(scale+o.scale-d.scale)) / o.unscaled at the scale, computed with one more digit to round
+	n, m := d.int(), o.int()
+	shift := scale + 1 + o.scale - d.scale
+	if shift >= 0 {
+		n = new(big.Int).Mul(n, pow10(shift))
+	} else {
+		m = new(big.Int).Mul(m, pow10(-shift))
+	}
+	q := new(big.Int).Quo(n, m)
+	return Decimal{unscaled: q, scale: scale + 1}.Round(scale)
+}
+
+// Round returns d rounded half away from zero to the scale, Round(2) of 1.005 is 1.01.
+// The scale of the result is the scale argument, the trailing zeros are kept: Round(2) of 1.5 is 1.50
+func (d Decimal) Round(scale int32) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	if scale >= d.scale {
+		return Decimal{unscaled: d.rescale(scale), scale: scale}
+	}
+	q, r := new(big.Int).QuoRem(d.int(), pow10(d.scale-scale), new(big.Int))
+	// |r| >= half of the divisor
+	if r.Abs(r).Mul(r, big.NewInt(2)).Cmp(pow10(d.scale-scale)) >= 0 {
+		q.Add(q, big.NewInt(int64(d.Sign())))
+	}
+	return Decimal{unscaled: q, scale: scale}
+}
+
+// Fits reports whether d can be stored in the DECIMAL(precision, scale) column without rounding or overflow,
+// the trailing zeros after the decimal point are ignored
+func (d Decimal) Fits(precision, scale int) bool {
+	s := d.String()
+	s = strings.TrimPrefix(s, "-")
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], strings.TrimRight(s[i+1:], "0")
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	return len(frac) <= scale && len(intPart) <= precision-scale
+}
+
+// Float64 returns the nearest float64 value of d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns the decimal string with Scale digits after the decimal point, -12.50
+func (d Decimal) String() string {
+	s := d.int().String()
+	if d.scale == 0 {
+		return s
+	}
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if n := int(d.scale) + 1 - len(s); n > 0 {
+		s = strings.Repeat("0", n) + s
+	}
+	s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Value implements driver.Valuer, the decimal is sent as the string to keep the precision
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (d *Decimal) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		return errors.New("xsql: can not scan NULL to Decimal, use NullDecimal")
+	case []byte:
+		*d, err = ParseDecimal(string(v))
+	case string:
+		*d, err = ParseDecimal(v)
+	case int64:
+		*d = NewDecimal(v, 0)
+	case float64:
+		// sqlite returns the REAL value
+		*d, err = ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("xsql: can not scan %T to Decimal", src)
+	}
+	return err
+}
+
+// FormatParam implements ParamFormatter, mysql compares and adds a string with a decimal as double,
+// the parameter is cast to DECIMAL to keep the precision
+func (d Decimal) FormatParam(placeholder string, info *StmtInfo) string {
+	return decimalParam(placeholder, info)
+}
+
+func decimalParam(placeholder string, info *StmtInfo) string {
+	if info.Dialect == MySQL || info.Dialect == "" {
+		return "CAST(" + placeholder + " AS DECIMAL(65,30))"
+	}
+	return placeholder
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Decimal) UnmarshalText(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, the decimal is a json string "12.50" to keep the precision
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, both the json string and number are accepted
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	return d.UnmarshalText([]byte(strings.Trim(s, `"`)))
+}
+
+// NullDecimal is a nullable Decimal like sql.NullString
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool // Valid is true if Decimal is not NULL
+}
+
+// Scan implements sql.Scanner
+func (n *NullDecimal) Scan(src interface{}) error {
+	if src == nil {
+		n.Decimal, n.Valid = Decimal{}, false
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.Scan(src)
+}
+
+// Value implements driver.Valuer
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+// FormatParam implements ParamFormatter
+func (n NullDecimal) FormatParam(placeholder string, info *StmtInfo) string {
+	return decimalParam(placeholder, info)
+}
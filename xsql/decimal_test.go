@@ -0,0 +1,197 @@
+package xsql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for _, c := range []struct {
+		in    string
+		want  string
+		scale int32
+	}{
+		{"0", "0", 0},
+		{"12", "12", 0},
+		{"-0.50", "-0.50", 2},
+		{"+1.5", "1.5", 1},
+		{".5", "0.5", 1},
+		{"-.05", "-0.05", 2},
+		{"1.", "1", 0},
+		{"007.10", "7.10", 2},
+		{"1.5e3", "1500", 0},
+		{"-1.5E-3", "-0.0015", 4},
+		{"12e-2", "0.12", 2},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", 9},
+	} {
+		d, err := ParseDecimal(c.in)
+		if err != nil || d.String() != c.want || d.Scale() != c.scale {
+			t.Errorf("ParseDecimal(%q) = %s scale %d, %v, want %s scale %d", c.in, d, d.Scale(), err, c.want, c.scale)
+		}
+	}
+	for _, in := range []string{"", "-", "+", ".", "1.2.3", "1e", "e3", "1e1.5", "+-1", " 1", "1_000", "abc", "0x10", "1e99999", "1e-99999"} {
+		if d, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %s, want error", in, d)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustDecimal
+	for _, c := range []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"zero value", Decimal{}, "0"},
+		{"negative scale", NewDecimal(12, -2), "1200"},
+		{"new", NewDecimal(-1999, 2), "-19.99"},
+		{"add scale grows", d("1.5").Add(d("0.25")), "1.75"},
+		{"add sign", d("-1.5").Add(d("0.25")), "-1.25"},
+		{"add zero value", Decimal{}.Add(d("0.10")), "0.10"},
+		{"sub below zero", d("0.1").Sub(d("0.30")), "-0.20"},
+		{"neg", d("2.50").Neg(), "-2.50"},
+		{"neg zero value", Decimal{}.Neg(), "0"},
+		{"mul scale is the sum", d("19.99").Mul(NewDecimal(3, 0)).Add(d("0.03")), "60.00"},
+		{"mul signs", d("-1.5").Mul(d("-0.2")), "0.30"},
+		{"mul negative", d("-1.5").Mul(d("0.2")), "-0.30"},
+		{"div", d("10").Div(d("3"), 4), "3.3333"},
+		{"div half up", d("2").Div(d("3"), 2), "0.67"},
+		{"div negative half", d("-1").Div(d("8"), 2), "-0.13"},
+		{"div signs", d("-1").Div(d("-8"), 2), "0.13"},
+		{"div exact", d("1.50").Div(d("0.5"), 0), "3"},
+		{"div divisor scale", d("1").Div(d("0.003"), 1), "333.3"},
+		{"div scale below dividend", d("1.23456").Div(d("1"), 2), "1.23"},
+		{"round half", d("1.005").Round(2), "1.01"},
+		{"round negative half", d("-1.005").Round(2), "-1.01"},
+		{"round .5", d("0.5").Round(0), "1"},
+		{"round -.5", d("-0.5").Round(0), "-1"},
+		{"round 2.5 away from zero", d("2.5").Round(0), "3"},
+		{"round below half", d("-0.49").Round(0), "0"},
+		{"round keeps trailing zeros", d("1.5").Round(2), "1.50"},
+		{"round negative scale", d("15.5").Round(-1), "16"},
+	} {
+		if c.got.String() != c.want {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+
+	if d("1.50").Cmp(d("1.5")) != 0 || !d("1.50").Equal(d("1.5")) || d("-2").Cmp(d("1")) != -1 || d("0.01").Cmp(Decimal{}) != 1 {
+		t.Errorf("Cmp compares the scaled values")
+	}
+	if d("-0.00").Sign() != 0 || !d("0.000").IsZero() || d("-0.01").Sign() != -1 || (Decimal{}).Sign() != 0 {
+		t.Errorf("Sign")
+	}
+	if f := d("-12.25").Float64(); f != -12.25 {
+		t.Errorf("Float64 = %v", f)
+	}
+	a := d("1.5")
+	a.Add(d("1")).Mul(d("2"))
+	if a.String() != "1.5" {
+		t.Errorf("the arithmetic changed the operand %s", a)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Div by zero does not panic")
+		}
+	}()
+	d("1").Div(Decimal{}, 2)
+}
+
+func TestDecimalFits(t *testing.T) {
+	for _, c := range []struct {
+		in               string
+		precision, scale int
+		want             bool
+	}{
+		{"999.99", 5, 2, true},
+		{"-999.99", 5, 2, true},
+		{"1000.00", 5, 2, false},
+		{"-1000", 5, 2, false},
+		{"0.001", 5, 2, false},
+		{"1.500", 5, 2, true},
+		{"0", 5, 2, true},
+		{"00012.3", 5, 2, true},
+		{"99999", 5, 0, true},
+		{"100000", 5, 0, false},
+		{"0.5", 5, 0, false},
+		{"0.99999", 5, 5, true},
+		{"1", 5, 5, false},
+		{"12345678901234567890123456789012345.123456789012345678901234567890", 65, 30, true},
+		{"123456789012345678901234567890123456", 65, 30, false},
+	} {
+		if got := MustDecimal(c.in).Fits(c.precision, c.scale); got != c.want {
+			t.Errorf("%s Fits(%d, %d) = %v, want %v", c.in, c.precision, c.scale, got, c.want)
+		}
+	}
+}
+
+func TestDecimalScanValue(t *testing.T) {
+	for _, c := range []struct {
+		src  interface{}
+		want string
+	}{
+		{[]byte("-12.50"), "-12.50"},
+		{"0.10", "0.10"},
+		{int64(-42), "-42"},
+		{float64(0.1), "0.1"},
+		{float64(-2.5e-7), "-0.00000025"},
+		{float64(1e21), "1000000000000000000000"},
+	} {
+		var d Decimal
+		if err := d.Scan(c.src); err != nil || d.String() != c.want {
+			t.Errorf("Scan(%#v) = %s, %v, want %s", c.src, d, err, c.want)
+		}
+	}
+	for _, src := range []interface{}{nil, []byte("x"), "1.2.3", true, int32(1)} {
+		var d Decimal
+		if err := d.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %s, want error", src, d)
+		}
+	}
+	if v, err := MustDecimal("-0.05").Value(); err != nil || v != "-0.05" {
+		t.Errorf("Value = %v, %v", v, err)
+	}
+
+	var n NullDecimal
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Errorf("NullDecimal Scan(nil) = %+v, %v", n, err)
+	}
+	if v, err := n.Value(); err != nil || v != nil {
+		t.Errorf("NULL Value = %v, %v", v, err)
+	}
+	if err := n.Scan([]byte("3.14")); err != nil || !n.Valid || n.Decimal.String() != "3.14" {
+		t.Errorf("NullDecimal Scan = %+v, %v", n, err)
+	}
+	if v, err := n.Value(); err != nil || v != "3.14" {
+		t.Errorf("Value = %v, %v", v, err)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A Decimal  `json:"a"`
+		B Decimal  `json:"b"`
+		C Decimal  `json:"c"`
+		D *Decimal `json:"d"`
+	}
+	v.C = MustDecimal("7")
+	if err := json.Unmarshal([]byte(`{"a":"-12.50","b":0.10,"c":null,"d":"1e2"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "-12.50" || v.B.String() != "0.10" || v.C.String() != "7" || v.D.String() != "100" {
+		t.Fatalf("Unmarshal = %s %s %s %s", v.A, v.B, v.C, v.D)
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) != `{"a":"-12.50","b":"0.10","c":"7","d":"100"}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+	for _, in := range []string{`{"a":"x"}`, `{"a":true}`, `{"a":"1.2.3"}`} {
+		if err := json.Unmarshal([]byte(in), &v); err == nil {
+			t.Errorf("Unmarshal(%s) = nil, want error", in)
+		}
+	}
+	if b, err := (Decimal{}).MarshalText(); err != nil || string(b) != "0" {
+		t.Errorf("MarshalText = %s, %v", b, err)
+	}
+}
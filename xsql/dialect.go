@@ -0,0 +1,86 @@
+package xsql
+
+// Dialects supported by the builders and the DB constructors.
+// The values are the database/sql driver names.
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// Dialecter is implemented by the ExecQuerier which knows the dialect of its database,
+// DB DebugDB and the ExecQuerier returned by WithDialect implement it.
+type Dialecter interface {
+	Dialect() string
+}
+
+// DialectOf returns the dialect of eq, or def if eq does not implement Dialecter.
+// The generated code use it to run on the database of another dialect, sqlite in unit tests for example.
+func DialectOf(eq ExecQuerier, def string) string {
+	if d, ok := eq.(Dialecter); ok && d.Dialect() != "" {
+		return d.Dialect()
+	}
+	return def
+}
+
+// WithDialect attaches the dialect to eq, such as *sql.Tx or *sql.DB opened by database/sql directly.
+func WithDialect(eq ExecQuerier, dialect string) ExecQuerier {
+	return &dialectEQ{ExecQuerier: eq, dialect: dialect}
+}
+
+type dialectEQ struct {
+	ExecQuerier
+	dialect string
+}
+
+// Dialect implements Dialecter.
+func (d *dialectEQ) Dialect() string {
+	return d.dialect
+}
+
+// DialectBuilder prefixes all root builders with the dialect.
+type DialectBuilder struct {
+	dialect string
+}
+
+// Dialect creates a new DialectBuilder with the given dialect name.
+//
+//	Dialect(Postgres).Select().From(Table("users"))
+func Dialect(name string) *DialectBuilder {
+	return &DialectBuilder{name}
+}
+
+// Insert creates an InsertBuilder for the configured dialect.
+func (d *DialectBuilder) Insert(table string) *InsertBuilder {
+	b := Insert(table)
+	b.SetDialect(d.dialect)
+	return b
+}
+
+// Update creates an UpdateBuilder for the configured dialect.
+func (d *DialectBuilder) Update(table string) *UpdateBuilder {
+	b := Update(table)
+	b.SetDialect(d.dialect)
+	return b
+}
+
+// Delete creates a DeleteBuilder for the configured dialect.
+func (d *DialectBuilder) Delete(table string) *DeleteBuilder {
+	b := Delete(table)
+	b.SetDialect(d.dialect)
+	return b
+}
+
+// Select creates a Selector for the configured dialect.
+func (d *DialectBuilder) Select(columns ...string) *Selector {
+	b := Select(columns...)
+	b.SetDialect(d.dialect)
+	return b
+}
+
+// Table creates a SelectTable for the configured dialect.
+func (d *DialectBuilder) Table(name string) *SelectTable {
+	b := Table(name)
+	b.SetDialect(d.dialect)
+	return b
+}
@@ -0,0 +1,212 @@
+package xsql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSON returns the sql.Scanner and driver.Valuer which unmarshal and marshal v by encoding/json,
+// v must be a pointer. The generated code use it for the nullable json column annotated by @type:
+//
+//	rows.Scan(xsql.JSON(&a.Settings))
+//	Insert("users").Set("settings", xsql.JSON(&a.Settings))
+func JSON(v interface{}) *JSONValue {
+	return &JSONValue{V: v}
+}
+
+// JSONNotNull returns the JSONValue of the NOT NULL json column, the nil map and slice are stored as {} and [],
+// the other nil values as the json null.
+func JSONNotNull(v interface{}) *JSONValue {
+	return &JSONValue{V: v, NotNull: true}
+}
+
+// JSONValue adapts a go value to a json column.
+type JSONValue struct {
+	V       interface{}
+	NotNull bool // the column is NOT NULL, the nil value is not stored as NULL
+}
+
+// Scan implements sql.Scanner, NULL leaves V unchanged.
+func (j *JSONValue) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j.V)
+	case string:
+		return json.Unmarshal([]byte(v), j.V)
+	default:
+		return fmt.Errorf("xsql: can not scan %T to json", src)
+	}
+}
+
+// Value implements driver.Valuer, nil pointer slice and map are stored as NULL unless NotNull is set.
+func (j *JSONValue) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) != "null" {
+		return string(b), nil
+	}
+	if !j.NotNull {
+		return nil, nil
+	}
+	v := reflect.ValueOf(j.V)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		return "{}", nil
+	case reflect.Slice:
+		return "[]", nil
+	}
+	return "null", nil
+}
+
+// JSONValueEQ returns a predicate reports whether the value at the path of the json column equals to value.
+// The path is a mysql json path like $.a.b[0], it is converted to the text array path of postgres.
+//
+//	JSON_UNQUOTE(JSON_EXTRACT(`settings`, '$.theme')) = 'dark'      mysql, same as `settings`->>'$.theme'
+//	"settings" #>> '{theme}' = 'dark'                               postgres
+//	json_extract(`settings`, '$.theme') = 'dark'                    sqlite
+func JSONValueEQ(col, path string, value interface{}) *Predicate {
+	return P().JSONValueEQ(col, path, value)
+}
+
+// JSONValueEQ appends a predicate reports whether the value at the path of the json column equals to value.
+func (p *Predicate) JSONValueEQ(col, path string, value interface{}) *Predicate {
+	return p.jsonValue(col, path, OpEQ, value)
+}
+
+// JSONValueNEQ returns a predicate reports whether the value at the path of the json column not equals to value.
+func JSONValueNEQ(col, path string, value interface{}) *Predicate {
+	return P().JSONValueNEQ(col, path, value)
+}
+
+// JSONValueNEQ appends a predicate reports whether the value at the path of the json column not equals to value.
+func (p *Predicate) JSONValueNEQ(col, path string, value interface{}) *Predicate {
+	return p.jsonValue(col, path, OpNEQ, value)
+}
+
+func (p *Predicate) jsonValue(col, path string, op Op, value interface{}) *Predicate {
+	return p.Append(func(b *Builder) {
+		switch {
+		case b.postgres():
+			// #>> returns text, compare with the text representation of the value
+			b.Ident(col).WriteString(" #>> ").Arg(pgJSONPath(path))
+			b.WriteOp(op)
+			b.Arg(fmt.Sprint(value))
+		case b.sqlite():
+			b.WriteString("json_extract(").Ident(col).Comma().Arg(path).WriteString(")")
+			b.WriteOp(op)
+			b.Arg(value)
+		default:
+			if _, ok := value.(string); ok {
+				b.WriteString("JSON_UNQUOTE(JSON_EXTRACT(").Ident(col).Comma().Arg(path).WriteString("))")
+				b.WriteOp(op)
+				b.Arg(value)
+				return
+			}
+			// compare the json number, boolean and null with the json value
+			v, err := json.Marshal(value)
+			if err != nil {
+				b.AddError(err)
+				return
+			}
+			b.WriteString("JSON_EXTRACT(").Ident(col).Comma().Arg(path).WriteString(")")
+			b.WriteOp(op)
+			b.WriteString("CAST(").Arg(string(v)).WriteString(" AS JSON)")
+		}
+	})
+}
+
+// JSONContains returns a predicate reports whether the json document at the path of the column contains value,
+// the value is marshaled by encoding/json. Use the path $ for the whole document.
+//
+//	JSON_CONTAINS(`tags`, '"go"', '$')                                   mysql
+//	("tags" #> '{}')::jsonb @> '"go"'::jsonb                             postgres
+//	EXISTS (SELECT 1 FROM json_each(`tags`, '$') WHERE value = 'go')    sqlite, only for the array of scalars
+func JSONContains(col, path string, value interface{}) *Predicate {
+	return P().JSONContains(col, path, value)
+}
+
+// JSONContains appends a predicate reports whether the json document at the path of the column contains value.
+func (p *Predicate) JSONContains(col, path string, value interface{}) *Predicate {
+	return p.Append(func(b *Builder) {
+		if b.sqlite() {
+			b.WriteString("EXISTS (SELECT 1 FROM json_each(").Ident(col).Comma().Arg(path).WriteString(") WHERE value = ").Arg(value).WriteString(")")
+			return
+		}
+		v, err := json.Marshal(value)
+		if err != nil {
+			b.AddError(err)
+			return
+		}
+		if b.postgres() {
+			b.WriteString("(").Ident(col).WriteString(" #> ").Arg(pgJSONPath(path)).WriteString(")::jsonb @> ").Arg(string(v)).WriteString("::jsonb")
+			return
+		}
+		b.WriteString("JSON_CONTAINS(").Ident(col).Comma().Arg(string(v)).Comma().Arg(path).WriteString(")")
+	})
+}
+
+// JSONHasKey returns a predicate reports whether the path exists in the json column.
+func JSONHasKey(col, path string) *Predicate {
+	return P().JSONHasKey(col, path)
+}
+
+// JSONHasKey appends a predicate reports whether the path exists in the json column.
+func (p *Predicate) JSONHasKey(col, path string) *Predicate {
+	return p.Append(func(b *Builder) {
+		switch {
+		case b.postgres():
+			b.Ident(col).WriteString(" #> ").Arg(pgJSONPath(path)).WriteString(" IS NOT NULL")
+		case b.sqlite():
+			b.WriteString("json_type(").Ident(col).Comma().Arg(path).WriteString(") IS NOT NULL")
+		default:
+			b.WriteString("JSON_CONTAINS_PATH(").Ident(col).WriteString(", 'one', ").Arg(path).WriteString(")")
+		}
+	})
+}
+
+// pgJSONPath converts the json path $.a."b.c"[0] to the postgres text array path {a,"b.c",0}
+func pgJSONPath(path string) string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var keys []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			if strings.HasPrefix(path, `"`) {
+				end := strings.Index(path[1:], `"`) + 2
+				if end < 2 {
+					end = len(path)
+				}
+				keys = append(keys, path[:end])
+				path = path[end:]
+				continue
+			}
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			keys = append(keys, path[:end])
+			path = path[end:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				end = len(path) - 1
+			}
+			keys = append(keys, path[1:end])
+			path = path[end+1:]
+		default:
+			path = path[1:]
+		}
+	}
+	return "{" + strings.Join(keys, ",") + "}"
+}
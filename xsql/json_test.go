@@ -0,0 +1,156 @@
+package xsql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJSONValue(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+	}
+	var (
+		m   map[string]int
+		s   []string
+		p   *settings
+		sp  = &settings{Theme: "dark"}
+		mp  *map[string]int
+		one = map[string]int{"a": 1}
+	)
+	for _, c := range []struct {
+		v    *JSONValue
+		want interface{}
+	}{
+		{JSON(&m), nil},
+		{JSON(&s), nil},
+		{JSON(&p), nil},
+		{JSON(&one), `{"a":1}`},
+		{JSONNotNull(&m), "{}"},
+		{JSONNotNull(&s), "[]"},
+		{JSONNotNull(&p), "null"},
+		{JSONNotNull(&mp), "null"},
+		{JSONNotNull(&sp), `{"theme":"dark"}`},
+		{JSONNotNull(&one), `{"a":1}`},
+		{JSONNotNull(m), "{}"},
+	} {
+		if got, err := c.v.Value(); err != nil || got != c.want {
+			t.Errorf("%T NotNull=%v Value = %#v, %v, want %#v", c.v.V, c.v.NotNull, got, err, c.want)
+		}
+	}
+	if _, err := JSON(func() {}).Value(); err == nil {
+		t.Errorf("Value of func = nil, want error")
+	}
+
+	m = map[string]int{"b": 2}
+	if err := JSON(&m).Scan(nil); err != nil || m["b"] != 2 {
+		t.Errorf("Scan(nil) = %v, %v", m, err)
+	}
+	if err := JSON(&m).Scan([]byte(`{"a":1}`)); err != nil || m["a"] != 1 {
+		t.Errorf("Scan = %v, %v", m, err)
+	}
+	if err := JSON(&s).Scan(`["x"]`); err != nil || len(s) != 1 || s[0] != "x" {
+		t.Errorf("Scan = %v, %v", s, err)
+	}
+	if err := JSON(&s).Scan(1); err == nil {
+		t.Errorf("Scan(1) = nil, want error")
+	}
+}
+
+func TestJSONPredicates(t *testing.T) {
+	predicates := []*Predicate{
+		JSONValueEQ("settings", "$.theme", "dark"),
+		JSONValueEQ("settings", "$.size", 12),
+		JSONValueNEQ("settings", `$.a."b.c"[0]`, true),
+		JSONContains("tags", "$", "go"),
+		JSONContains("settings", "$.ids", 1),
+		JSONHasKey("settings", "$.theme"),
+	}
+	for _, c := range []struct {
+		dialect string
+		want    []string
+	}{
+		{MySQL, []string{
+			"JSON_UNQUOTE(JSON_EXTRACT(`settings`, ?)) = ? [$.theme dark]",
+			"JSON_EXTRACT(`settings`, ?) = CAST(? AS JSON) [$.size 12]",
+			"JSON_EXTRACT(`settings`, ?) <> CAST(? AS JSON) [$.a.\"b.c\"[0] true]",
+			"JSON_CONTAINS(`tags`, ?, ?) [\"go\" $]",
+			"JSON_CONTAINS(`settings`, ?, ?) [1 $.ids]",
+			"JSON_CONTAINS_PATH(`settings`, 'one', ?) [$.theme]",
+		}},
+		{Postgres, []string{
+			`"settings" #>> $1 = $2 [{theme} dark]`,
+			`"settings" #>> $1 = $2 [{size} 12]`,
+			`"settings" #>> $1 <> $2 [{a,"b.c",0} true]`,
+			`("tags" #> $1)::jsonb @> $2::jsonb [{} "go"]`,
+			`("settings" #> $1)::jsonb @> $2::jsonb [{ids} 1]`,
+			`"settings" #> $1 IS NOT NULL [{theme}]`,
+		}},
+		{SQLite, []string{
+			"json_extract(`settings`, ?) = ? [$.theme dark]",
+			"json_extract(`settings`, ?) = ? [$.size 12]",
+			"json_extract(`settings`, ?) <> ? [$.a.\"b.c\"[0] true]",
+			"EXISTS (SELECT 1 FROM json_each(`tags`, ?) WHERE value = ?) [$ go]",
+			"EXISTS (SELECT 1 FROM json_each(`settings`, ?) WHERE value = ?) [$.ids 1]",
+			"json_type(`settings`, ?) IS NOT NULL [$.theme]",
+		}},
+	} {
+		d := Dialect(c.dialect)
+		for i, p := range predicates {
+			query, args := d.Select().From(d.Table("t")).Where(p).Query()
+			got := fmt.Sprintf("%s %v", query[strings.Index(query, " WHERE ")+len(" WHERE "):], args)
+			if got != c.want[i] {
+				t.Errorf("%s predicate %d = %s, want %s", c.dialect, i, got, c.want[i])
+			}
+		}
+	}
+}
+
+func TestJSONPredicatesSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY, settings TEXT NOT NULL, tags TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	var empty []string
+	for _, v := range [][]interface{}{
+		{1, JSONNotNull(&map[string]interface{}{"theme": "dark", "size": 12, "ids": []int{1, 2}, "a": map[string]interface{}{"b.c": []bool{true}}}), JSONNotNull(&[]string{"go", "sql"})},
+		{2, JSONNotNull(&map[string]interface{}{"theme": "light", "size": 10, "a": map[string]interface{}{"b.c": []bool{false}}}), JSONNotNull(&empty)},
+	} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO t (id, settings, tags) VALUES (?, ?, ?)", v...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := Dialect(SQLite)
+	for _, c := range []struct {
+		p    *Predicate
+		want string
+	}{
+		{JSONValueEQ("settings", "$.theme", "dark"), "[1]"},
+		{JSONValueEQ("settings", "$.size", 10), "[2]"},
+		{JSONValueNEQ("settings", `$.a."b.c"[0]`, true), "[2]"},
+		{JSONContains("tags", "$", "go"), "[1]"},
+		{JSONContains("settings", "$.ids", 2), "[1]"},
+		{JSONHasKey("settings", "$.ids"), "[1]"},
+		{JSONHasKey("settings", "$.missing"), "[]"},
+	} {
+		query, args := d.Select("id").From(d.Table("t")).Where(c.p).OrderBy("id").Query()
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			t.Fatal(query, err)
+		}
+		ids := []int64{}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if got := fmt.Sprint(ids); got != c.want {
+			t.Errorf("%s %v = %s, want %s", query, args, got, c.want)
+		}
+	}
+}
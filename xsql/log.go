@@ -21,8 +21,19 @@ type DebugDB struct {
 }
 
 type DebugTx struct {
-	log Logger
-	eq  ExecQuerier
+	log     Logger
+	eq      ExecQuerier
+	dialect string
+}
+
+// Dialect returns the dialect of the debugged db
+func (d *DebugDB) Dialect() string {
+	return DialectOf(d.dbt, "")
+}
+
+// Dialect returns the dialect of the debugged db
+func (d *DebugTx) Dialect() string {
+	return d.dialect
 }
 
 func (d *DebugDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
@@ -37,12 +48,12 @@ func (d *DebugDB) QueryContext(ctx context.Context, query string, args ...interf
 
 func (d *DebugDB) Begin() (*DebugTx, error) {
 	tx, err := d.dbt.Begin()
-	return &DebugTx{eq: tx, log: d.log}, err
+	return &DebugTx{eq: tx, log: d.log, dialect: d.Dialect()}, err
 }
 
 func (d *DebugDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*DebugTx, error) {
 	tx, err := d.dbt.BeginTx(ctx, opts)
-	return &DebugTx{eq: tx, log: d.log}, err
+	return &DebugTx{eq: tx, log: d.log, dialect: d.Dialect()}, err
 }
 
 func (d *DebugTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
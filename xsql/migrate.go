@@ -0,0 +1,444 @@
+package xsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MigrationsTable is the bookkeeping table of the applied migrations.
+const MigrationsTable = "schema_migrations"
+
+// migrationLock is the name of the advisory lock held while migrating.
+const migrationLock = "crud:" + MigrationsTable
+
+// MigrationsLockTable is the lock of sqlite which has no advisory lock, the migrator holds its row while migrating.
+const MigrationsLockTable = MigrationsTable + "_lock"
+
+// lockRetry is the interval of trying the lock of postgres and sqlite
+var lockRetry = 200 * time.Millisecond
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a version of the schema, read from the files NNN_name.up.sql and NNN_name.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string // sql of the .up.sql file
+	Down    string // sql of the .down.sql file, empty if the migration can not be rolled back
+	prefix  string // version in the file name, may have leading zeros
+}
+
+func (m *Migration) file(up bool) string {
+	if up {
+		return m.prefix + "_" + m.Name + ".up.sql"
+	}
+	return m.prefix + "_" + m.Name + ".down.sql"
+}
+
+// MigrationStatus is a migration and whether it is applied.
+type MigrationStatus struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations to the master of db.
+// All the statements are run by one connection which holds a lock so the concurrent deploys don't race:
+// GET_LOCK for mysql, pg_try_advisory_lock for postgres and the row of MigrationsLockTable for sqlite.
+// Every migration is run in a transaction on postgres and sqlite, mysql commits DDL implicitly.
+type Migrator struct {
+	db          *DB
+	migrations  []*Migration
+	LockTimeout time.Duration // how long to wait for the lock, default 1 minute
+	// AllowOutOfOrder applies the pending migration older than the latest applied one,
+	// it is an error by default because the migration is likely merged from a branch after the newer ones are deployed
+	AllowOutOfOrder bool
+}
+
+// NewMigrator reads the migrations from fsys, use os.DirFS("migrations") for a directory
+// or embed.FS for the migrations embedded in the binary.
+func NewMigrator(db *DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, v := range entries {
+		if v.IsDir() {
+			continue
+		}
+		match := migrationFile.FindStringSubmatch(v.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("xsql/migrate: %s: %w", v.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, v.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2], prefix: match[1]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] || m.prefix != match[1] {
+			return nil, fmt.Errorf("xsql/migrate: duplicate version %d: %s and %s", version, m.file(true), v.Name())
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+	migrator := &Migrator{db: db, LockTimeout: time.Minute}
+	for _, v := range byVersion {
+		if v.Up == "" {
+			return nil, fmt.Errorf("xsql/migrate: %s not exist or empty", v.file(true))
+		}
+		migrator.migrations = append(migrator.migrations, v)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	return migrator, nil
+}
+
+// Migrations returns the migrations ordered by version.
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Up applies all the pending migrations, returns the applied migrations.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	return m.Goto(ctx, -1)
+}
+
+// Down rolls back the last applied migration, returns the rolled back migration.
+func (m *Migrator) Down(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+	err := m.run(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			v := m.migrations[i]
+			if _, ok := applied[v.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, v, false); err != nil {
+				return err
+			}
+			done = append(done, v)
+			return nil
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Goto migrates the schema to the version, applies the pending migrations <= version
+// and rolls back the applied migrations > version in descending order.
+// Goto(ctx, 0) rolls back all the migrations and a negative version applies all.
+// Nothing is migrated if a pending migration is older than the latest applied one, unless AllowOutOfOrder is set.
+func (m *Migrator) Goto(ctx context.Context, version int64) ([]*Migration, error) {
+	var done []*Migration
+	err := m.run(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		var pending []*Migration
+		var latest int64
+		for _, v := range m.migrations {
+			if version >= 0 && v.Version > version {
+				break
+			}
+			if _, ok := applied[v.Version]; ok {
+				latest = v.Version
+			} else {
+				pending = append(pending, v)
+			}
+		}
+		if len(pending) > 0 && pending[0].Version < latest && !m.AllowOutOfOrder {
+			return fmt.Errorf("xsql/migrate: %s is older than the applied version %d, set AllowOutOfOrder to apply it", pending[0].file(true), latest)
+		}
+		for i := len(m.migrations) - 1; i >= 0 && version >= 0; i-- {
+			v := m.migrations[i]
+			if _, ok := applied[v.Version]; !ok || v.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, v, false); err != nil {
+				return err
+			}
+			done = append(done, v)
+		}
+		for _, v := range pending {
+			if err := m.apply(ctx, conn, v, true); err != nil {
+				return err
+			}
+			done = append(done, v)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns all the migrations with the applied time.
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	var res []*MigrationStatus
+	err := m.run(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, v := range m.migrations {
+			at, ok := applied[v.Version]
+			res = append(res, &MigrationStatus{Migration: v, Applied: ok, AppliedAt: at})
+		}
+		return nil
+	})
+	return res, err
+}
+
+// run locks the migrations on a connection of master and calls fn with the applied versions.
+func (m *Migrator) run(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]time.Time) error) (err error) {
+	conn, err := m.db.Master().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := unlock(); err == nil {
+			err = uerr
+		}
+	}()
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+MigrationsTable+
+		" (version bigint NOT NULL PRIMARY KEY, name varchar(255) NOT NULL, applied_at bigint NOT NULL)"); err != nil {
+		return fmt.Errorf("xsql/migrate: create %s: %w", MigrationsTable, err)
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	switch m.db.Dialect() {
+	case MySQL:
+		var ok sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLock, int64(m.LockTimeout/time.Second)).Scan(&ok); err != nil {
+			return nil, fmt.Errorf("xsql/migrate: get lock: %w", err)
+		}
+		if ok.Int64 != 1 {
+			return nil, fmt.Errorf("xsql/migrate: get lock %s timeout, another migration is running", migrationLock)
+		}
+		return func() error {
+			_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLock)
+			return err
+		}, nil
+	case Postgres:
+		h := fnv.New64a()
+		h.Write([]byte(migrationLock))
+		key := int64(h.Sum64())
+		err := m.acquire(ctx, func() (ok bool, err error) {
+			err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok)
+			return ok, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+			return err
+		}, nil
+	}
+	// sqlite has no advisory lock, the row of the lock table is inserted by only one migrator
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+MigrationsLockTable+
+		" (id integer NOT NULL PRIMARY KEY, locked_at bigint NOT NULL)"); err != nil {
+		return nil, fmt.Errorf("xsql/migrate: create %s: %w", MigrationsLockTable, err)
+	}
+	err := m.acquire(ctx, func() (bool, error) {
+		res, err := conn.ExecContext(ctx, "INSERT OR IGNORE INTO "+MigrationsLockTable+" (id, locked_at) VALUES (1, ?)", time.Now().Unix())
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		return n == 1, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, delete the row of %s if the migration is crashed", err, MigrationsLockTable)
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.Background(), "DELETE FROM "+MigrationsLockTable+" WHERE id = 1")
+		return err
+	}, nil
+}
+
+// acquire calls try until it gets the lock, LockTimeout passes or ctx is done
+func (m *Migrator) acquire(ctx context.Context, try func() (bool, error)) error {
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		ok, err := try()
+		if err != nil {
+			return fmt.Errorf("xsql/migrate: get lock: %w", err)
+		}
+		if ok {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("xsql/migrate: get lock %s timeout, another migration is running", migrationLock)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	query, args := Dialect(m.db.Dialect()).Select("version", "applied_at").From(Table(MigrationsTable)).Query()
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("xsql/migrate: query %s: %w", MigrationsTable, err)
+	}
+	defer rows.Close()
+	res := map[int64]time.Time{}
+	for rows.Next() {
+		var version, at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		res[version] = time.Unix(at, 0)
+	}
+	return res, rows.Err()
+}
+
+// apply runs the up or down sql of the migration statement by statement and records the version.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, v *Migration, up bool) (err error) {
+	body := v.Up
+	if !up {
+		body = v.Down
+		if strings.TrimSpace(body) == "" {
+			return fmt.Errorf("xsql/migrate: %s not exist or empty, can not roll back", v.file(false))
+		}
+	}
+	var eq ExecQuerier = conn
+	if m.db.Dialect() != MySQL {
+		var tx *sql.Tx
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+		eq = tx
+	}
+	for i, stmt := range splitStatements(body, m.db.Dialect() == MySQL) {
+		if _, err := eq.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("xsql/migrate: %s statement %d: %w\n%s", v.file(up), i+1, err, stmt)
+		}
+	}
+	var query string
+	var args []interface{}
+	if up {
+		query, args = Dialect(m.db.Dialect()).Insert(MigrationsTable).
+			Columns("version", "name", "applied_at").
+			Values(v.Version, v.Name, time.Now().Unix()).Query()
+	} else {
+		query, args = Dialect(m.db.Dialect()).Delete(MigrationsTable).Where(EQ("version", v.Version)).Query()
+	}
+	if _, err := eq.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("xsql/migrate: record %s: %w", v.file(up), err)
+	}
+	return nil
+}
+
+// splitStatements splits the sql script by semicolons which are not in quotes, comments
+// or the dollar quoted strings of postgres. The statements only contain comments are dropped.
+// mysql supports # comments, backslash escapes and the /*! ... */ version comments which mysql runs as sql,
+// the DELIMITER command of the mysql client is not supported.
+func splitStatements(script string, mysql bool) []string {
+	var (
+		res   []string
+		start int
+		code  bool // the statement contains sql other than comments
+	)
+	add := func(end int) {
+		if code {
+			res = append(res, strings.TrimSpace(script[start:end]))
+		}
+		start, code = end+1, false
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == ';':
+			add(i)
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#' && mysql:
+			if j := strings.IndexByte(script[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if mysql && strings.HasPrefix(script[i:], "/*!") {
+				// the version comment of mysqldump, /*!40101 SET NAMES utf8mb4 */, is sql
+				code = true
+			}
+			if j := strings.Index(script[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(script)
+			}
+		case c == '\'' || c == '"' || c == '`':
+			code = true
+			for i++; i < len(script); i++ {
+				if script[i] == '\\' && c == '\'' && mysql {
+					i++
+				} else if script[i] == c {
+					if i+1 < len(script) && script[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case c == '$' && !mysql:
+			code = true
+			tag := script[i:]
+			if j := strings.IndexByte(tag[1:], '$'); j >= 0 && isDollarTag(tag[1:j+1]) {
+				tag = tag[:j+2]
+				if k := strings.Index(script[i+len(tag):], tag); k >= 0 {
+					i += len(tag) + k + len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			code = true
+		}
+	}
+	if start < len(script) {
+		add(len(script))
+	}
+	return res
+}
+
+func isDollarTag(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+package xsql
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, c := range []struct {
+		script string
+		mysql  bool
+		want   []string
+	}{
+		{"CREATE TABLE a (id int);\nINSERT INTO a VALUES (1)", true, []string{"CREATE TABLE a (id int)", "INSERT INTO a VALUES (1)"}},
+		{"-- only comment;\n/* block; */\n# hash;\n;  ;", true, nil},
+		{"INSERT INTO a VALUES ('x;y', \"a;b\", `c;d`, 'it''s;', 'back\\';slash');", true,
+			[]string{"INSERT INTO a VALUES ('x;y', \"a;b\", `c;d`, 'it''s;', 'back\\';slash')"}},
+		{"SELECT 1; -- tail; comment\nSELECT 2 /* ; */;", true, []string{"SELECT 1", "-- tail; comment\nSELECT 2 /* ; */"}},
+		// the version comments of mysqldump are sql
+		{"/*!40101 SET NAMES utf8mb4 */;\n/*!40014 SET FOREIGN_KEY_CHECKS=0 */;\nCREATE TABLE a (id int) /*!50100 PARTITION BY HASH (id) */;\n/* plain */;",
+			true, []string{"/*!40101 SET NAMES utf8mb4 */", "/*!40014 SET FOREIGN_KEY_CHECKS=0 */", "CREATE TABLE a (id int) /*!50100 PARTITION BY HASH (id) */"}},
+		{"/*!40101 SET @a = 1; */;", true, []string{"/*!40101 SET @a = 1; */"}},
+		{"/*! not mysql */;", false, nil},
+		{"# not a comment of postgres;", false, []string{"# not a comment of postgres"}},
+		{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nSELECT $tag$ ; $tag$;", false,
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT $tag$ ; $tag$"}},
+		{"SELECT 'a\\';b'", false, []string{"SELECT 'a\\'", "b'"}},
+	} {
+		got := splitStatements(c.script, c.mysql)
+		if strings.Join(got, "\n--\n") != strings.Join(c.want, "\n--\n") {
+			t.Errorf("splitStatements(%q)\n%q\nwant\n%q", c.script, got, c.want)
+		}
+	}
+}
+
+func openSQLite(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(SQLite, &Config{DSN: "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)", Active: 2, Idle: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Master().Close() })
+	return db
+}
+
+func migrator(t *testing.T, db *DB, files map[string]string) *Migrator {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for name, body := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(body)}
+	}
+	m, err := NewMigrator(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func versions(ms []*Migration) []int64 {
+	var res []int64
+	for _, v := range ms {
+		res = append(res, v.Version)
+	}
+	return res
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	files := map[string]string{
+		"001_user.up.sql":   "CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT NOT NULL);\n-- seed\nINSERT INTO user (name) VALUES ('a;b');",
+		"001_user.down.sql": "DROP TABLE user;",
+		"002_age.up.sql":    "ALTER TABLE user ADD COLUMN age INTEGER NOT NULL DEFAULT 0;",
+		"002_age.down.sql":  "ALTER TABLE user DROP COLUMN age;",
+		"003_bad.up.sql":    "CREATE TABLE tag (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);",
+		"readme.md":         "ignored",
+	}
+	m := migrator(t, db, files)
+	done, err := m.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "003_bad.up.sql statement 2") || len(done) != 2 {
+		t.Fatalf("Up = %v, %v", versions(done), err)
+	}
+	// the failed migration is rolled back
+	var n int
+	if err := db.Master().QueryRow("SELECT count(*) FROM sqlite_master WHERE name = 'tag'").Scan(&n); err != nil || n != 0 {
+		t.Fatalf("tag table = %d, %v", n, err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil || len(status) != 3 || !status[0].Applied || !status[1].Applied || status[2].Applied || status[0].AppliedAt.IsZero() {
+		t.Fatalf("Status = %v", err)
+	}
+	if done, err := m.Down(ctx); err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Down = %v, %v", versions(done), err)
+	}
+	if done, err := m.Goto(ctx, 0); err != nil || len(done) != 1 || done[0].Version != 1 {
+		t.Fatalf("Goto(0) = %v, %v", versions(done), err)
+	}
+	if done, err := m.Goto(ctx, 2); err != nil || len(done) != 2 {
+		t.Fatalf("Goto(2) = %v, %v", versions(done), err)
+	}
+	var name string
+	if err := db.Master().QueryRow("SELECT name FROM user WHERE age = 0").Scan(&name); err != nil || name != "a;b" {
+		t.Fatalf("name = %s, %v", name, err)
+	}
+
+	for _, files := range []map[string]string{
+		{"001_a.up.sql": "SELECT 1", "001_b.up.sql": "SELECT 1"},
+		{"01_a.up.sql": "SELECT 1", "1_a.down.sql": "SELECT 1"},
+		{"001_a.down.sql": "SELECT 1"},
+	} {
+		fsys := fstest.MapFS{}
+		for name, body := range files {
+			fsys[name] = &fstest.MapFile{Data: []byte(body)}
+		}
+		if _, err := NewMigrator(db, fsys); err == nil {
+			t.Errorf("NewMigrator(%v) = nil, want error", files)
+		}
+	}
+}
+
+func TestMigratorOutOfOrder(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	if _, err := migrator(t, db, map[string]string{"001_a.up.sql": "CREATE TABLE a (id int)", "003_c.up.sql": "CREATE TABLE c (id int)"}).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"001_a.up.sql": "CREATE TABLE a (id int)",
+		"002_b.up.sql": "CREATE TABLE b (id int)",
+		"003_c.up.sql": "CREATE TABLE c (id int)", "003_c.down.sql": "DROP TABLE c",
+		"004_d.up.sql": "CREATE TABLE d (id int)",
+	}
+	m := migrator(t, db, files)
+	if done, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "002_b.up.sql is older than the applied version 3") || len(done) != 0 {
+		t.Fatalf("Up = %v, %v", versions(done), err)
+	}
+	// the older migration is in order after rolling back the newer one
+	if done, err := m.Goto(ctx, 2); err != nil || len(done) != 2 || done[0].Version != 3 || done[1].Version != 2 {
+		t.Fatalf("Goto(2) = %v, %v", versions(done), err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 2 {
+		t.Fatalf("Up in order = %v, %v", versions(done), err)
+	}
+
+	db = openSQLite(t)
+	if _, err := migrator(t, db, map[string]string{"003_c.up.sql": "CREATE TABLE c (id int)"}).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	m = migrator(t, db, map[string]string{"001_a.up.sql": "CREATE TABLE a (id int)", "003_c.up.sql": "x"})
+	m.AllowOutOfOrder = true
+	if done, err := m.Up(ctx); err != nil || len(done) != 1 || done[0].Version != 1 {
+		t.Fatalf("Up out of order = %v, %v", versions(done), err)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	defer func(d time.Duration) { lockRetry = d }(lockRetry)
+	lockRetry = 10 * time.Millisecond
+	ctx := context.Background()
+	db := openSQLite(t)
+	m := migrator(t, db, map[string]string{"001_a.up.sql": "CREATE TABLE a (id int)"})
+	m.LockTimeout = 50 * time.Millisecond
+	if _, err := m.Status(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the lock is released after migrating, another migrator holds it now
+	if _, err := db.ExecContext(ctx, "INSERT INTO "+MigrationsLockTable+" (id, locked_at) VALUES (1, 0)"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if done, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "timeout") || !strings.Contains(err.Error(), MigrationsLockTable) || len(done) != 0 {
+		t.Fatalf("Up = %v, %v", versions(done), err)
+	}
+	if time.Since(start) < m.LockTimeout {
+		t.Fatalf("Up returns before LockTimeout")
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	m.LockTimeout = time.Minute
+	if _, err := m.Up(cctx); err == nil {
+		t.Fatalf("Up with the canceled context = nil")
+	}
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		db.ExecContext(ctx, "DELETE FROM "+MigrationsLockTable)
+	}()
+	if done, err := m.Up(ctx); err != nil || len(done) != 1 {
+		t.Fatalf("Up after the lock is released = %v, %v", versions(done), err)
+	}
+	var n int
+	if err := db.Master().QueryRow("SELECT count(*) FROM " + MigrationsLockTable).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("lock rows = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,13 @@
+// Package postgres registers the lib/pq driver and opens the postgres db of xsql
+package postgres
+
+import (
+	_ "github.com/lib/pq"
+
+	"github.com/hongshengjie/crud/xsql"
+)
+
+// New open postgres db by lib/pq driver, the builders must be created by Dialect(Postgres)
+func New(c *xsql.Config) (*xsql.DB, error) {
+	return xsql.Open(xsql.Postgres, c)
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 )
 
+// ErrStop is returned by the callback of the generated Each and Batches to stop the iteration without error.
+var ErrStop = errors.New("xsql: stop iteration")
+
 type ColumnScanner interface {
 	Close() error
 	ColumnTypes() ([]*sql.ColumnType, error)
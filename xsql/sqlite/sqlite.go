@@ -0,0 +1,19 @@
+// Package sqlite registers the pure go driver modernc.org/sqlite and opens the sqlite db of xsql,
+// the driver is large, only the programs importing this package link it
+package sqlite
+
+import (
+	_ "modernc.org/sqlite"
+
+	"github.com/hongshengjie/crud/xsql"
+)
+
+// New open sqlite db, the DSN is the database file
+// like "file:test.db?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)".
+// ReadDSN is ignored, sqlite has no replica. Every connection of ":memory:" is a different database,
+// use "file::memory:?cache=shared" or set Active to 1 for an in-memory database shared by the connections.
+func New(c *xsql.Config) (*xsql.DB, error) {
+	sc := *c
+	sc.ReadDSN = nil
+	return xsql.Open(xsql.SQLite, &sc)
+}